@@ -0,0 +1,313 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Repository backends: every repository type (git, a local directory or a static
+// HTTP index) exposes the same small interface, so syncing and looking up recipes
+// doesn't care where the recipes actually come from.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// repository types selected with `type` in the repository config
+const (
+	RepoTypeGit   = "git"   // git clone verified through signed commits (default)
+	RepoTypeLocal = "local" // plain directory on disk, for overlays and development
+	RepoTypeHTTP  = "http"  // static web server serving a signed index.json and recipes
+)
+
+// Repository is implemented by every repository backend
+type Repository interface {
+	// Name returns the repository name from the config
+	Name() string
+	// RecipeDir returns the local directory holding <pkg>.json and <pkg>.toml recipes
+	RecipeDir() string
+	// Sync brings the local copy up to date and verifies it, last is
+	// the state recorded after the previous successful sync
+	Sync(ctx context.Context, force bool, last RepoState) error
+}
+
+// openRepository returns the backend for a configured repository
+func openRepository(repo RepoConfig) (Repository, error) {
+	switch repo.Kind() {
+	case RepoTypeGit:
+		return &gitRepository{cfg: repo}, nil
+	case RepoTypeLocal:
+		return &localRepository{cfg: repo}, nil
+	case RepoTypeHTTP:
+		return &httpRepository{cfg: repo}, nil
+	default:
+		return nil, fmt.Errorf("repository %s has unknown type %q (expected %s, %s or %s)",
+			repo.Name, repo.Type, RepoTypeGit, RepoTypeLocal, RepoTypeHTTP)
+	}
+}
+
+// sortedRepoNames returns repository names in a stable order, so nothing
+// depends on Go's random map iteration
+func sortedRepoNames(repos map[string]RepoConfig) []string {
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//===================================================================//
+//							 Local directories
+//===================================================================//
+
+// localRepository reads recipes straight from a directory on disk. Nothing is
+// copied or verified at the repository level: the directory is as trusted as
+// whoever can write to it. Recipe signatures still apply if configured.
+type localRepository struct {
+	cfg RepoConfig
+}
+
+func (l *localRepository) Name() string { return l.cfg.Name }
+
+func (l *localRepository) RecipeDir() string {
+	return filepath.Join(l.cfg.Path, "recipes")
+}
+
+func (l *localRepository) Sync(ctx context.Context, force bool, last RepoState) error {
+	if !filepath.IsAbs(l.cfg.Path) {
+		return fmt.Errorf("local repository %s needs an absolute path, got %q", l.cfg.Name, l.cfg.Path)
+	}
+
+	info, err := os.Stat(l.RecipeDir())
+	if err != nil {
+		return fmt.Errorf("local repository %s: %v", l.cfg.Name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("local repository %s: %s is not a directory", l.cfg.Name, l.RecipeDir())
+	}
+
+	eyes.Infof("Using local repository %s at %s", l.cfg.Name, l.cfg.Path)
+	return nil
+}
+
+//===================================================================//
+//							 Static HTTP indexes
+//===================================================================//
+
+// HTTPIndex is the index.json served at the root of a static HTTP repository,
+// it is signed with a detached signature (index.json.sig) by a pinned key
+type HTTPIndex struct {
+	Timestamp int64            `json:"timestamp"` // unix time the index was generated, must never go backwards
+	Recipes   []HTTPIndexEntry `json:"recipes"`   // every recipe in the repository
+}
+
+// HTTPIndexEntry points at one recipe of a static HTTP repository
+type HTTPIndexEntry struct {
+	Name      string `json:"name"`                // package name
+	Path      string `json:"path"`                // recipe path relative to the repository URL (eg. "recipes/foo.json" or "recipes/foo.toml")
+	Sha256    string `json:"sha256"`              // digest of the recipe file
+	Signature string `json:"signature,omitempty"` // optional detached maintainer signature, relative path
+}
+
+// httpRepository mirrors a static HTTP repository into the local repository
+// directory. The signed index pins the digest of every recipe, so only the
+// index signature needs a key, the recipes themselves are checked by hash.
+type httpRepository struct {
+	cfg RepoConfig
+}
+
+func (h *httpRepository) Name() string { return h.cfg.Name }
+
+// Dir is where the repository is mirrored
+func (h *httpRepository) Dir() string {
+	return filepath.Join(LocalRepositoryDirPath, h.cfg.Name)
+}
+
+func (h *httpRepository) RecipeDir() string {
+	return filepath.Join(h.Dir(), "recipes")
+}
+
+// resolve joins a path from the index onto the repository URL
+func (h *httpRepository) resolve(rel string) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(h.cfg.BaseURL, "/") + "/")
+	if err != nil {
+		return "", fmt.Errorf("invalid repository url %q: %v", h.cfg.BaseURL, err)
+	}
+	ref, err := url.Parse(rel)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// fetch downloads a file of the repository into dest
+func (h *httpRepository) fetch(ctx context.Context, rel, dest string) error {
+	u, err := h.resolve(rel)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := downloadClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s, status: %s", u, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("failed to write %s: %v", dest, err)
+	}
+	return nil
+}
+
+// safeIndexPath makes sure a path from the index stays inside the repository
+func safeIndexPath(p string) (string, error) {
+	clean := path.Clean(p)
+	if clean == "." || path.IsAbs(clean) || strings.HasPrefix(clean, "../") || clean == ".." || strings.Contains(p, "://") {
+		return "", fmt.Errorf("unsafe path %q in index", p)
+	}
+	return clean, nil
+}
+
+// Sync downloads and verifies the signed index, then mirrors every recipe into
+// a staging directory which only replaces the current copy once everything checked out
+func (h *httpRepository) Sync(ctx context.Context, force bool, last RepoState) error {
+	name := h.cfg.Name
+
+	if h.cfg.BaseURL == "" {
+		return fmt.Errorf("http repository %s has no url", name)
+	}
+	if len(h.cfg.TrustedKeys) == 0 {
+		return fmt.Errorf("http repository %s must pin trusted_keys to verify its index", name)
+	}
+
+	staging := h.Dir() + ".new"
+	_ = os.RemoveAll(staging)
+	defer os.RemoveAll(staging)
+
+	indexPath := filepath.Join(staging, "index.json")
+	if err := h.fetch(ctx, "index.json", indexPath); err != nil {
+		return fmt.Errorf("repository %s: %v", name, err)
+	}
+	if err := h.fetch(ctx, "index.json.sig", indexPath+".sig"); err != nil {
+		return fmt.Errorf("repository %s: %v", name, err)
+	}
+
+	sig, err := gpgVerifyDetached(ctx, h.cfg.TrustedKeys, indexPath+".sig", indexPath)
+	if err != nil {
+		return fmt.Errorf("repository %s index failed signature verification: %v", name, err)
+	}
+	eyes.Infof("Index of %s signed by %s (%s)", name, sig.Signer, sig.Fingerprint)
+
+	raw, err := os.ReadFile(indexPath)
+	if err != nil {
+		return err
+	}
+	var index HTTPIndex
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return fmt.Errorf("repository %s has an invalid index: %v", name, err)
+	}
+
+	sum := sha256.Sum256(raw)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	generated := time.Unix(index.Timestamp, 0)
+
+	// the index timestamp plays the role of commit ancestry for git repositories
+	if last.Commit != "" && last.Commit != digest && !generated.After(last.CommitTime) {
+		return fmt.Errorf("repository %s: index from %s is not newer than the last verified one from %s (rollback?)",
+			name, generated.Format(time.RFC3339), last.CommitTime.Format(time.RFC3339))
+	}
+	if err := checkRepoFreshness(name, h.cfg, generated); err != nil {
+		return err
+	}
+
+	for _, entry := range index.Recipes {
+		if entry.Name == "" || strings.ContainsAny(entry.Name, `/\`) {
+			return fmt.Errorf("repository %s: invalid package name %q in index", name, entry.Name)
+		}
+		rel, err := safeIndexPath(entry.Path)
+		if err != nil {
+			return fmt.Errorf("repository %s: %v", name, err)
+		}
+
+		dest := filepath.Join(staging, "recipes", entry.Name+"."+recipeFormat(rel)) // recipes may be TOML too
+		if err := h.fetch(ctx, rel, dest); err != nil {
+			return fmt.Errorf("repository %s: %v", name, err)
+		}
+
+		actual, err := fileChecksum("sha256", dest)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(actual, entry.Sha256) {
+			return fmt.Errorf("repository %s: recipe %s does not match the index (expected sha256 %s, got %s)",
+				name, entry.Name, entry.Sha256, actual)
+		}
+
+		if entry.Signature != "" {
+			sigRel, err := safeIndexPath(entry.Signature)
+			if err != nil {
+				return fmt.Errorf("repository %s: %v", name, err)
+			}
+			if err := h.fetch(ctx, sigRel, recipeSignaturePath(dest)); err != nil {
+				return fmt.Errorf("repository %s: %v", name, err)
+			}
+		}
+	}
+
+	// swap the verified copy in
+	if err := os.RemoveAll(h.Dir()); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, h.Dir()); err != nil {
+		return fmt.Errorf("failed to install repository %s: %v", name, err)
+	}
+
+	eyes.Infof("Repository %s synced (%d recipes)", name, len(index.Recipes))
+	return recordVerifiedCommit(name, digest, generated)
+}
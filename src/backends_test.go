@@ -0,0 +1,55 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import "testing"
+
+func TestSafeIndexPath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "recipes/foo.json", want: "recipes/foo.json"},
+		{in: "./recipes//foo.toml", want: "recipes/foo.toml"},
+		{in: "recipes/../foo.json", want: "foo.json"}, // still inside
+		{in: "foo..json", want: "foo..json"},
+		{in: "..foo/bar.json", want: "..foo/bar.json"},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "recipes/..", wantErr: true},
+		{in: "..", wantErr: true},
+		{in: "../foo.json", wantErr: true},
+		{in: "recipes/../../foo.json", wantErr: true},
+		{in: "/etc/passwd", wantErr: true},
+		{in: "https://example.com/foo.json", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := safeIndexPath(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("safeIndexPath(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("safeIndexPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
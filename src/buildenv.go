@@ -0,0 +1,182 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Build environments. Every build command gets an environment built from
+// scratch instead of Blink's own, so neither the user's shell nor an earlier
+// package of the same run leaks into a build. Layers, later ones win:
+//
+//  1. baseline: PATH, HOME, TMPDIR, LANG
+//  2. settings: MAKEFLAGS, CFLAGS, CXXFLAGS, LDFLAGS and the proxy
+//  3. the build profile, when there is one (see profile.go)
+//  4. recipe variables, build scripts only (see vars.go)
+//  5. the recipe's build.env
+//
+// `blink env <pkg>` prints the result with where each variable comes from.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// build environment layers, what `blink env` says a variable comes from
+const (
+	EnvFromBaseline = "baseline"
+	EnvFromSettings = "settings"
+	EnvFromProfile  = "profile" // followed by the profile name
+	EnvFromVars     = "vars"
+	EnvFromRecipe   = "recipe"
+)
+
+// baselinePath is the PATH of every build
+const baselinePath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// proxyEnv are passed on from Blink's environment, where the proxy setting puts them too
+var proxyEnv = []string{"http_proxy", "https_proxy", "no_proxy", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+// EnvVar is one variable of a build environment
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // the layer it comes from, EnvFrom*
+}
+
+// BuildEnv is the environment of one package's build commands
+type BuildEnv struct {
+	Profile string // build profile, empty for none
+	vars    map[string]EnvVar
+}
+
+// newBuildEnv builds the environment of pkg, whose build.env has been expanded
+// already. vars is only exported when script is set
+func newBuildEnv(pkg PackageInfo, vars *RecipeVars, script bool) (*BuildEnv, error) {
+	profile, err := packageProfile(pkg.Name)
+	if err != nil {
+		return nil, err
+	}
+	e := &BuildEnv{Profile: profile, vars: map[string]EnvVar{}}
+
+	e.set("PATH", baselinePath, EnvFromBaseline)
+	e.set("HOME", buildHome(), EnvFromBaseline)
+	e.set("TMPDIR", "/tmp", EnvFromBaseline)
+	e.set("LANG", "C", EnvFromBaseline)
+
+	for k, v := range CurrentSettings.BuildEnv() {
+		e.set(k, v, EnvFromSettings)
+	}
+	for _, name := range proxyEnv {
+		if v := os.Getenv(name); v != "" {
+			e.set(name, v, EnvFromSettings)
+		}
+	}
+
+	if profile != "" {
+		for k, v := range BuildProfiles[profile].BuildEnv(CurrentSettings) {
+			e.set(k, v, EnvFromProfile+" "+profile)
+		}
+	}
+
+	if script {
+		env, err := vars.Environ()
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range env {
+			k, v, _ := strings.Cut(kv, "=")
+			e.set(k, v, EnvFromVars)
+		}
+	}
+
+	for k, v := range pkg.Build.Env {
+		e.set(k, v, EnvFromRecipe)
+	}
+	return e, nil
+}
+
+// set adds or replaces a variable
+func (e *BuildEnv) set(name, value, source string) {
+	e.vars[name] = EnvVar{Name: name, Value: value, Source: source}
+}
+
+// Vars returns the variables sorted by name
+func (e *BuildEnv) Vars() []EnvVar {
+	out := make([]EnvVar, 0, len(e.vars))
+	for _, name := range sortedKeys(e.vars) {
+		out = append(out, e.vars[name])
+	}
+	return out
+}
+
+// Environ returns the environment as NAME=value, for exec.Cmd.Env
+func (e *BuildEnv) Environ() []string {
+	env := make([]string, 0, len(e.vars))
+	for _, v := range e.Vars() {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// buildHome is HOME for builds, the build user's home or root's
+func buildHome() string {
+	name := CurrentSettings.BuildUser
+	if name == "" {
+		name = "root"
+	}
+	if u, err := user.Lookup(name); err == nil && u.HomeDir != "" {
+		return u.HomeDir
+	}
+	return "/root"
+}
+
+// packageEnv returns the build environment of a package as its build would
+// get it, ${srcdir} stays as is since it's only known once the source is unpacked
+func packageEnv(path string, force bool, pkgName string) (*BuildEnv, error) {
+	pkg, err := fetchpkg(path, force, pkgName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := newRecipeVars(pkg, filepath.Join(BuildDirPath, pkg.Name))
+	if err != nil {
+		return nil, err
+	}
+	vars.SetSrcDir("${srcdir}")
+	if err := vars.ExpandBuild(&pkg); err != nil {
+		return nil, err
+	}
+	return newBuildEnv(pkg, vars, usesBuildScript(pkg))
+}
+
+// printBuildEnv prints a build environment as a table
+func printBuildEnv(env *BuildEnv) {
+	if env.Profile != "" {
+		fmt.Printf("Build profile: %s\n\n", env.Profile)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVALUE\tFROM")
+	for _, v := range env.Vars() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Value, v.Source)
+	}
+	w.Flush()
+}
@@ -0,0 +1,171 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Build systems. Most recipes would spell out the same configure, make and
+// make install lines, build.system names the build system instead and Blink
+// fills in the standard commands for it. Flags come from the build environment
+// (see buildenv.go), files are installed into ${destdir} and build.args reaches
+// the step that takes options (configure, cmake, meson setup, ...). A command
+// list the recipe does set replaces the generated one for that phase.
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BuildSystem holds the default commands of a build.system. ${...} in them are
+// recipe variables like everywhere else, {args} becomes the quoted build.args
+type BuildSystem struct {
+	Prepare []string
+	Build   []string
+	Check   []string // the test suite, only run with --check
+	Install []string // installs into ${destdir}, Blink removes what it installed on uninstall
+}
+
+// buildSystems are the values build.system takes
+var buildSystems = map[string]BuildSystem{
+	"autotools": {
+		Prepare: []string{
+			"[ -x ./configure ] || autoreconf -fi",
+			"./configure --prefix=/usr --sysconfdir=/etc --localstatedir=/var {args}",
+		},
+		Build:   []string{"make"},
+		Check:   []string{"make check"},
+		Install: []string{`make DESTDIR="${destdir}" install`},
+	},
+	"make": {
+		Build:   []string{"make PREFIX=/usr {args}"},
+		Install: []string{`make PREFIX=/usr DESTDIR="${destdir}" {args} install`},
+	},
+	"cmake": {
+		// build type None leaves the optimization flags to CFLAGS
+		Prepare: []string{"cmake -S . -B blink-build -DCMAKE_INSTALL_PREFIX=/usr -DCMAKE_BUILD_TYPE=None {args}"},
+		Build:   []string{"cmake --build blink-build --parallel ${jobs}"},
+		Check:   []string{"ctest --test-dir blink-build --output-on-failure -j ${jobs}"},
+		Install: []string{`DESTDIR="${destdir}" cmake --install blink-build`},
+	},
+	"meson": {
+		Prepare: []string{"meson setup blink-build --prefix=/usr --buildtype=plain {args}"},
+		Build:   []string{"meson compile -C blink-build -j ${jobs}"},
+		Check:   []string{"meson test -C blink-build --print-errorlogs"},
+		Install: []string{`meson install -C blink-build --no-rebuild --destdir "${destdir}"`},
+	},
+	"cargo": {
+		Build:   []string{"cargo build --release {args}"},
+		Check:   []string{"cargo test --release {args}"},
+		Install: []string{`cargo install --path . --root "${destdir}/usr" {args}`},
+	},
+	"go": {
+		Build:   []string{"go build -trimpath -o blink-build/${name} {args} ."},
+		Check:   []string{"go test {args} ./..."},
+		Install: []string{`install -Dm755 blink-build/${name} "${destdir}/usr/bin/${name}"`},
+	},
+	"python": {
+		Build:   []string{"python3 -m pip wheel --no-deps --no-build-isolation -w blink-build {args} ."},
+		Install: []string{`python3 -m pip install --root="${destdir}" --prefix=/usr --no-deps --no-index blink-build/*.whl`},
+	},
+}
+
+// commandList is one of a recipe's build command lists
+type commandList struct {
+	Name string
+	Cmds *[]string
+}
+
+// commandLists returns the command lists of a recipe, in the order they run
+func commandLists(pkg *PackageInfo) []commandList {
+	return []commandList{
+		{"prepare", &pkg.Build.Prepare},
+		{"build", &pkg.Build.Build},
+		{"check", &pkg.Build.Check},
+		{"install", &pkg.Build.Install},
+		{"uninstall", &pkg.Build.Uninstall},
+	}
+}
+
+// lookupBuildSystem returns the build system a recipe names
+func lookupBuildSystem(pkg PackageInfo) (BuildSystem, error) {
+	name := strings.ToLower(strings.TrimSpace(pkg.Build.System))
+	sys, ok := buildSystems[name]
+	if !ok {
+		return BuildSystem{}, fmt.Errorf("unknown build system %q (known: %s)", pkg.Build.System, strings.Join(sortedKeys(buildSystems), ", "))
+	}
+	switch {
+	case !strings.EqualFold(strings.TrimSpace(pkg.Build.Kind), "toCompile"):
+		return BuildSystem{}, fmt.Errorf("build.system is only for toCompile recipes")
+	case usesBuildScript(pkg):
+		return BuildSystem{}, fmt.Errorf("a recipe with a build script can't have a build.system, call the build system in the script")
+	}
+	return sys, nil
+}
+
+// applyBuildSystem fills the command lists a recipe leaves empty with the
+// ones of its build.system, before the recipe variables are expanded
+func applyBuildSystem(pkg *PackageInfo) error {
+	if pkg.Build.System == "" {
+		if len(pkg.Build.Args) > 0 {
+			return fmt.Errorf("build.args needs a build.system")
+		}
+		return nil
+	}
+
+	sys, err := lookupBuildSystem(*pkg)
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(pkg.Build.Args))
+	for i, arg := range pkg.Build.Args {
+		quoted[i] = shellQuote(arg)
+	}
+	args := strings.Join(quoted, " ")
+
+	defaults := map[string][]string{
+		"prepare": sys.Prepare,
+		"build":   sys.Build,
+		"check":   sys.Check,
+		"install": sys.Install,
+	}
+	for _, list := range commandLists(pkg) {
+		if len(*list.Cmds) > 0 {
+			continue // the recipe overrides this phase
+		}
+		cmds := make([]string, len(defaults[list.Name]))
+		for i, cmd := range defaults[list.Name] {
+			cmds[i] = strings.TrimSpace(strings.ReplaceAll(cmd, "{args}", args))
+		}
+		*list.Cmds = cmds
+	}
+	return nil
+}
+
+// shellSafe matches words that don't need quoting in sh
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s as a single sh word, the shell expands nothing in it.
+// Recipe variables are expanded anyway, Blink replaces them before sh runs
+func shellQuote(s string) string {
+	if s != "" && shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
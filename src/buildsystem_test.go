@@ -0,0 +1,154 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"--disable-static", "--disable-static"},
+		{"--prefix=/usr", "--prefix=/usr"},
+		{"CFLAGS=-O2", "CFLAGS=-O2"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+		{"a;rm -rf /", "'a;rm -rf /'"},
+		{"`id`", "'`id`'"},
+	}
+	for _, tt := range tests {
+		got := shellQuote(tt.in)
+		if got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+			continue
+		}
+
+		// sh has to get the word back unchanged
+		out, err := exec.Command("sh", "-c", "printf %s "+got).Output()
+		if err != nil {
+			t.Fatalf("sh: %v", err)
+		}
+		if string(out) != tt.in {
+			t.Errorf("sh turned %s into %q, want %q", got, out, tt.in)
+		}
+	}
+}
+
+func TestApplyBuildSystem(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(pkg *PackageInfo)
+		want    map[string][]string // command lists after, by name
+		wantErr string
+	}{
+		{
+			name: "autotools with args",
+			build: func(pkg *PackageInfo) {
+				pkg.Build.System = "autotools"
+				pkg.Build.Args = []string{"--disable-static", "--with-x=a b"}
+			},
+			want: map[string][]string{
+				"prepare": {"[ -x ./configure ] || autoreconf -fi", "./configure --prefix=/usr --sysconfdir=/etc --localstatedir=/var --disable-static '--with-x=a b'"},
+				"build":   {"make"},
+				"check":   {"make check"},
+				"install": {`make DESTDIR="${destdir}" install`},
+			},
+		},
+		{
+			name: "recipe lists override the system's",
+			build: func(pkg *PackageInfo) {
+				pkg.Build.System = "Meson"
+				pkg.Build.Install = []string{"true"}
+			},
+			want: map[string][]string{
+				"prepare": {"meson setup blink-build --prefix=/usr --buildtype=plain"},
+				"build":   {"meson compile -C blink-build -j ${jobs}"},
+				"check":   {"meson test -C blink-build --print-errorlogs"},
+				"install": {"true"},
+			},
+		},
+		{
+			name:  "no system",
+			build: func(pkg *PackageInfo) { pkg.Build.Install = []string{"make install"} },
+			want:  map[string][]string{"install": {"make install"}},
+		},
+		{
+			name:    "args without a system",
+			build:   func(pkg *PackageInfo) { pkg.Build.Args = []string{"-x"} },
+			wantErr: "build.args needs a build.system",
+		},
+		{
+			name:    "unknown system",
+			build:   func(pkg *PackageInfo) { pkg.Build.System = "scons" },
+			wantErr: `unknown build system "scons"`,
+		},
+		{
+			name: "precompiled",
+			build: func(pkg *PackageInfo) {
+				pkg.Build.Kind = "preCompiled"
+				pkg.Build.System = "make"
+			},
+			wantErr: "only for toCompile",
+		},
+		{
+			name: "with a build script",
+			build: func(pkg *PackageInfo) {
+				pkg.Build.System = "make"
+				pkg.Build.Script = "package() { :; }"
+			},
+			wantErr: "build script",
+		},
+	}
+	for _, tt := range tests {
+		pkg := PackageInfo{Name: "foo"}
+		pkg.Build.Kind = "toCompile"
+		tt.build(&pkg)
+
+		err := applyBuildSystem(&pkg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+
+		got := map[string][]string{}
+		for _, list := range commandLists(&pkg) {
+			if len(*list.Cmds) > 0 {
+				got[list.Name] = *list.Cmds
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: command lists = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
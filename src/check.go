@@ -0,0 +1,105 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Check phase. Recipes can run the upstream test suite between building and
+// installing, build.check commands or a check() in the build script. It only
+// runs with --check or check = true in [settings], a package can opt out with
+// skip_check in its [packages.<name>] table. A failing check aborts the
+// install, its output is kept in LogDirPath since the build dir goes away
+// with the next build.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// checksRequested reports whether --check or the config asks for checks
+func checksRequested() bool {
+	return RunChecks || CurrentSettings.Check
+}
+
+// checksEnabled reports whether the check phase of pkgName runs
+func checksEnabled(pkgName string) bool {
+	return checksRequested() && !PackageConfigs[pkgName].SkipCheck
+}
+
+// checkLogPath is where the output of a package's check phase is kept
+func checkLogPath(pkg PackageInfo) string {
+	return filepath.Join(LogDirPath, fmt.Sprintf("%s-%s-%d.check.log", pkg.Name, pkg.Version, pkg.Release))
+}
+
+// runChecks runs the check phase of pkg, with script in script mode and the
+// build.check commands otherwise, in the current directory
+func runChecks(pkg PackageInfo, script *BuildScript, env *BuildEnv, cred *syscall.Credential) error {
+	if !checksEnabled(pkg.Name) {
+		if checksRequested() {
+			eyes.Infof("%s: skip_check is set, not running its tests", pkg.Name)
+		}
+		return nil
+	}
+	if script == nil && len(pkg.Build.Check) == 0 {
+		eyes.Infof("%s: the recipe has no check commands", pkg.Name)
+		return nil
+	}
+
+	if err := os.MkdirAll(LogDirPath, 0750); err != nil {
+		return err
+	}
+	logPath := checkLogPath(pkg)
+	_ = os.Remove(logPath) // one log per run
+
+	if script != nil {
+		return script.run("check", cred, logPath)
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	eyes.Infof("%s: running the check commands", pkg.Name)
+	started := time.Now()
+
+	for _, line := range pkg.Build.Check {
+		fmt.Fprintf(logFile, "==> %s\n", line)
+
+		cmd := exec.Command("sh", "-c", line)
+		cmd.Env = env.Environ()
+		cmd.Stdout = io.MultiWriter(os.Stdout, logFile)
+		cmd.Stderr = io.MultiWriter(os.Stderr, logFile)
+		if cred != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: check failed after %s: %v (output in %s)", pkg.Name, time.Since(started).Round(time.Millisecond), err, logPath)
+		}
+	}
+	eyes.Infof("%s: checks passed in %s, output in %s", pkg.Name, time.Since(started).Round(time.Millisecond), logPath)
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Aperture-OS/eyes"
+	"golang.org/x/crypto/blake2b"
+)
+
+// checksum algorithms a recipe can declare in its source block,
+// in the order they are verified
+var SupportedChecksums = []string{"sha256", "sha512", "blake2b"}
+
+// ChecksumSkip is the value a recipe puts in place of a digest to
+// explicitly opt out of verification (eg. for sources that change every download)
+const ChecksumSkip = "SKIP"
+
+// checksum policies, see ChecksumPolicy in globals.go
+const (
+	ChecksumPolicyStrict     = "strict"     // reject recipes with missing or SKIP checksums
+	ChecksumPolicyPermissive = "permissive" // warn about them and carry on
+)
+
+// ChecksumMismatchError is returned when a downloaded source does not match
+// one of the digests declared in its recipe, it carries everything needed
+// to tell the user what went wrong without having to dig through logs
+type ChecksumMismatchError struct {
+	URL       string // source URL the file was downloaded from
+	File      string // local path of the downloaded file
+	Algorithm string // algorithm that failed (sha256, sha512, blake2b)
+	Expected  string // digest declared in the recipe
+	Actual    string // digest computed from the file
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for %s\n  file:     %s\n  expected: %s\n  actual:   %s",
+		e.Algorithm, e.URL, e.File, e.Expected, e.Actual)
+}
+
+// declaredChecksums returns the digests declared by the recipe keyed by
+// algorithm, empty values are left out so callers only see what was set
+func declaredChecksums(pkg PackageInfo) map[string]string {
+	declared := map[string]string{
+		"sha256":  strings.TrimSpace(pkg.Source.Sha256),
+		"sha512":  strings.TrimSpace(pkg.Source.Sha512),
+		"blake2b": strings.TrimSpace(pkg.Source.Blake2b),
+	}
+
+	for algo, sum := range declared {
+		if sum == "" {
+			delete(declared, algo)
+		}
+	}
+
+	return declared
+}
+
+// newChecksumHash returns a fresh hash.Hash for the given algorithm name
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "blake2b":
+		return blake2b.New512(nil) // same digest as b2sum
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algo)
+	}
+}
+
+// fileChecksum computes the hex encoded digest of file using algo
+func fileChecksum(algo, file string) (string, error) {
+	h, err := newChecksumHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifySourceChecksums checks the downloaded source of pkg against every
+// digest the recipe declares. Missing checksums and SKIP markers are handled
+// according to ChecksumPolicy, a mismatch is always fatal and returns a
+// *ChecksumMismatchError with the expected and actual values.
+func verifySourceChecksums(pkg PackageInfo, file string) error {
+	declared := declaredChecksums(pkg)
+
+	if len(declared) == 0 {
+		if ChecksumPolicy == ChecksumPolicyStrict {
+			return fmt.Errorf("recipe %s declares no checksum for %s (checksum policy is %q)",
+				pkg.Name, pkg.Source.URL, ChecksumPolicy)
+		}
+		eyes.Warnf("Recipe %s declares no checksum for %s, source integrity is NOT verified!", pkg.Name, pkg.Source.URL)
+		return nil
+	}
+
+	for _, algo := range SupportedChecksums {
+		expected, ok := declared[algo]
+		if !ok {
+			continue
+		}
+
+		if strings.EqualFold(expected, ChecksumSkip) {
+			if ChecksumPolicy == ChecksumPolicyStrict {
+				return fmt.Errorf("recipe %s marks its %s checksum as %s (checksum policy is %q)",
+					pkg.Name, algo, ChecksumSkip, ChecksumPolicy)
+			}
+			eyes.Warnf("Recipe %s skips %s verification for %s", pkg.Name, algo, pkg.Source.URL)
+			continue
+		}
+
+		actual, err := fileChecksum(algo, file)
+		if err != nil {
+			return fmt.Errorf("failed to compute %s of %s: %v", algo, file, err)
+		}
+
+		if !strings.EqualFold(actual, expected) {
+			return &ChecksumMismatchError{
+				URL:       pkg.Source.URL,
+				File:      file,
+				Algorithm: algo,
+				Expected:  strings.ToLower(expected),
+				Actual:    actual,
+			}
+		}
+
+		eyes.Infof("%s checksum verified for %s", algo, file)
+	}
+
+	return nil
+}
+
+// validChecksumPolicy reports whether policy is one Blink understands
+func validChecksumPolicy(policy string) bool {
+	return policy == ChecksumPolicyStrict || policy == ChecksumPolicyPermissive
+}
@@ -0,0 +1,95 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySourceChecksums(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "foo-1.0.tar.gz")
+	if err := os.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// digests of "hello\n", as sha256sum, sha512sum and b2sum print them
+	const (
+		sha256Sum  = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+		sha512Sum  = "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629"
+		blake2bSum = "f60ce482e5cc1229f39d71313171a8d9f4ca3a87d066bf4b205effb528192a75f14f3271e2c1a90e1de53f275b4d4793eef2f5e31ea90d2ce29d2e481c36435f"
+		wrong      = "0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	tests := []struct {
+		name     string
+		policy   string
+		sha256   string
+		sha512   string
+		blake2b  string
+		wantErr  string // part of the error, "" when none is expected
+		mismatch string // algorithm of the expected *ChecksumMismatchError
+	}{
+		{name: "sha256", policy: ChecksumPolicyStrict, sha256: sha256Sum},
+		{name: "sha512", policy: ChecksumPolicyStrict, sha512: sha512Sum},
+		{name: "blake2b", policy: ChecksumPolicyStrict, blake2b: blake2bSum},
+		{name: "all three, upper case", policy: ChecksumPolicyStrict, sha256: strings.ToUpper(sha256Sum), sha512: sha512Sum, blake2b: blake2bSum},
+		{name: "SKIP, strict", policy: ChecksumPolicyStrict, sha256: "SKIP", wantErr: "marks its sha256 checksum as SKIP"},
+		{name: "SKIP, permissive", policy: ChecksumPolicyPermissive, sha256: "skip"},
+		{name: "SKIP next to a digest, permissive", policy: ChecksumPolicyPermissive, sha256: "SKIP", sha512: sha512Sum},
+		{name: "none, strict", policy: ChecksumPolicyStrict, wantErr: "declares no checksum"},
+		{name: "none, permissive", policy: ChecksumPolicyPermissive},
+		{name: "sha256 mismatch", policy: ChecksumPolicyPermissive, sha256: wrong, mismatch: "sha256"},
+		{name: "blake2b mismatch after a good sha256", policy: ChecksumPolicyStrict, sha256: sha256Sum, blake2b: wrong, mismatch: "blake2b"},
+	}
+
+	defer func(policy string) { ChecksumPolicy = policy }(ChecksumPolicy)
+	for _, tt := range tests {
+		ChecksumPolicy = tt.policy
+		pkg := PackageInfo{Name: "foo"}
+		pkg.Source.URL = "https://example.com/foo-1.0.tar.gz"
+		pkg.Source.Sha256 = tt.sha256
+		pkg.Source.Sha512 = tt.sha512
+		pkg.Source.Blake2b = tt.blake2b
+
+		err := verifySourceChecksums(pkg, file)
+		switch {
+		case tt.mismatch != "":
+			var mismatch *ChecksumMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Errorf("%s: error = %v, want a *ChecksumMismatchError", tt.name, err)
+				continue
+			}
+			if mismatch.Algorithm != tt.mismatch || mismatch.Expected != wrong || mismatch.File != file {
+				t.Errorf("%s: mismatch = %+v, want %s expecting %s", tt.name, mismatch, tt.mismatch, wrong)
+			}
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+	}
+}
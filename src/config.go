@@ -18,18 +18,156 @@
   along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
 */
 
+// The config file (config.toml) is versioned: version 2 has a [settings] table for
+// global options and one [repos.<name>] table per repository, optionally build
+// profiles in [profiles.<name>] and per-package options in [packages.<name>]. Older configs which
+// only had top-level repository tables are migrated in memory, `blink config migrate`
+// rewrites them. Unknown keys are errors, reported with their line, so a typo never gets silently ignored.
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"net/url"
 	"os"
+	"os/user"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Aperture-OS/eyes"
 	"github.com/BurntSushi/toml"
 )
 
-// CreateDefaultConfig writes the default repository config to configPath
+// ConfigVersion is the config schema version this Blink reads and writes
+const ConfigVersion = 2
+
+// DefaultSettings returns the settings used for everything config.toml leaves out
+func DefaultSettings() Settings {
+	return Settings{
+		Jobs:           0, // one per CPU
+		DefaultRoot:    "/",
+		Parallelism:    4,
+		SyncTimeout:    "10m",
+		ChecksumPolicy: ChecksumPolicyStrict,
+	}
+}
+
+// withDefaults fills every empty setting with its default
+func (s Settings) withDefaults() Settings {
+	def := DefaultSettings()
+	if s.DefaultRoot == "" {
+		s.DefaultRoot = def.DefaultRoot
+	}
+	if s.Parallelism == 0 {
+		s.Parallelism = def.Parallelism
+	}
+	if s.SyncTimeout == "" {
+		s.SyncTimeout = def.SyncTimeout
+	}
+	if s.ChecksumPolicy == "" {
+		s.ChecksumPolicy = def.ChecksumPolicy
+	}
+	return s
+}
+
+// JobCount returns the number of parallel build jobs
+func (s Settings) JobCount() int {
+	if s.Jobs > 0 {
+		return s.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// settingDuration parses a timeout setting, empty or "0" means no limit
+func settingDuration(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// BuildEnv returns the environment the settings add to every build
+func (s Settings) BuildEnv() map[string]string {
+	env := map[string]string{
+		"MAKEFLAGS":        fmt.Sprintf("-j%d", s.JobCount()),
+		"CARGO_BUILD_JOBS": fmt.Sprint(s.JobCount()),
+	}
+	if s.CFlags != "" {
+		env["CFLAGS"] = s.CFlags
+		env["CXXFLAGS"] = s.CFlags
+	}
+	if s.LDFlags != "" {
+		env["LDFLAGS"] = s.LDFlags
+	}
+	return env
+}
+
+// BuildCredential returns the credential build steps run with,
+// nil when no build_user is configured (build as root)
+func (s Settings) BuildCredential() (*syscall.Credential, error) {
+	if s.BuildUser == "" {
+		return nil, nil
+	}
+
+	u, err := user.Lookup(s.BuildUser)
+	if err != nil {
+		return nil, fmt.Errorf("build user %q: %v", s.BuildUser, err)
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("build user %q has invalid uid %q", s.BuildUser, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("build user %q has invalid gid %q", s.BuildUser, u.Gid)
+	}
+
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
+
+// applySettings makes settings the effective ones for this run
+func applySettings(s Settings) error {
+	s = s.withDefaults()
+	CurrentSettings = s
+	ChecksumPolicy = s.ChecksumPolicy
+	if ChecksumPolicyOverride != "" {
+		ChecksumPolicy = ChecksumPolicyOverride // --checksum-policy wins over the config
+	}
+
+	if s.CacheDir != "" {
+		SourceDirPath = filepath.Join(s.CacheDir, "sources")
+		BuildDirPath = filepath.Join(s.CacheDir, "build")
+		for _, dir := range []string{SourceDirPath, BuildDirPath} {
+			if err := os.MkdirAll(dir, 0750); err != nil {
+				return fmt.Errorf("failed to create cache dir %s: %v", dir, err)
+			}
+		}
+	}
+
+	// a proxy from the environment wins, it's the more specific one
+	if s.Proxy != "" {
+		for _, name := range []string{"http_proxy", "https_proxy", "HTTP_PROXY", "HTTPS_PROXY"} {
+			if os.Getenv(name) == "" {
+				os.Setenv(name, s.Proxy)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CreateDefaultConfig writes the default config to ConfigFilePath
 func CreateDefaultConfig() error {
 	if ConfigFilePath == "" {
 		return fmt.Errorf("ConfigFilePath is empty")
@@ -40,39 +178,26 @@ func CreateDefaultConfig() error {
 		return err
 	}
 
-	// Write the raw TOML directly
-	defaultConfig := `[pseudoRepository]
-git_url = "https://github.com/Aperture-OS/testing-blink-repo.git"
-branch = "main"
-`
-	if err := os.WriteFile(ConfigFilePath, []byte(defaultConfig), 0640); err != nil {
+	// Write the raw TOML directly, so the commented settings stay in
+	if err := os.WriteFile(ConfigFilePath, []byte(DefaultConfig), 0640); err != nil {
 		return fmt.Errorf("failed to write default config: %v", err)
 	}
 
-	eyes.Infof("Default repository config created at %s", ConfigFilePath)
+	eyes.Infof("Default config created at %s", ConfigFilePath)
 	return nil
 }
 
+// EnsureConfig creates the default config if there is none yet
 func EnsureConfig() error {
 	if _, err := os.Stat(ConfigFilePath); os.IsNotExist(err) {
 		eyes.Infof("Config file not found. Creating default at %s", ConfigFilePath)
-		if err := os.MkdirAll(filepath.Dir(ConfigFilePath), 0750); err != nil {
-			return fmt.Errorf("failed to create config dir: %v", err)
-		}
-
-		defaultConfig := `[pseudoRepository]
-git_url = "https://github.com/Aperture-OS/testing-blink-repo.git"
-branch = "main"
-trustedKey = "/key.pub"
-`
-		if err := os.WriteFile(ConfigFilePath, []byte(defaultConfig), 0640); err != nil {
-			return fmt.Errorf("failed to write default config: %v", err)
-		}
+		return CreateDefaultConfig()
 	}
 	return nil
 }
 
-// LoadConfig loads the repository config from configPath
+// LoadConfig loads the config from ConfigFilePath, applies its settings
+// and returns the enabled and disabled repositories
 func LoadConfig() (map[string]RepoConfig, error) {
 	if _, err := os.Stat(ConfigFilePath); os.IsNotExist(err) {
 		eyes.Infof("Config file not found. Creating default config at %s", ConfigFilePath)
@@ -81,46 +206,709 @@ func LoadConfig() (map[string]RepoConfig, error) {
 		}
 	}
 
-	var repos map[string]RepoConfig
-	if _, err := toml.DecodeFile(ConfigFilePath, &repos); err != nil {
-		return nil, fmt.Errorf("failed to decode config TOML: %v", err)
+	cfg, err := loadConfigFile(ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySettings(cfg.Settings); err != nil {
+		return nil, err
 	}
+	BuildProfiles = mergeProfiles(cfg.Profiles)
+	PackageConfigs = cfg.Packages
 
-	if len(repos) == 0 {
+	if len(cfg.Repos) == 0 {
 		return nil, fmt.Errorf("no repositories found in config")
 	}
 
-	eyes.Infof("Loaded %d repositories from %s", len(repos), ConfigFilePath)
-	return repos, nil
+	eyes.Infof("Loaded %d repositories from %s", len(cfg.Repos), ConfigFilePath)
+	return cfg.Repos, nil
 }
 
-// LoadRepos reads repository definitions from a TOML file
+// LoadRepos reads the repository definitions from a config file
 func LoadRepos(path string) (map[string]RepoConfig, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("repo config does not exist: %s", path)
 	}
 
-	var raw map[string]struct {
-		GitURL string `toml:"git_url"`
-		Branch string `toml:"branch"`
-		Hash   string `toml:"hash"`
-		Key    string `toml:"trusted_key"`
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Repos, nil
+}
+
+// oldConfigNoted is set once the user was told about an old config, the
+// config is loaded more than once per run
+var oldConfigNoted bool
+
+// loadConfigFile reads and validates a config file, an old one is migrated
+// in memory only and the file is left as it is
+func loadConfigFile(path string) (Config, error) {
+	data, version, err := readConfigFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if version < ConfigVersion && !oldConfigNoted {
+		oldConfigNoted = true
+		eyes.Infof("Config %s uses config version %d, run 'blink config migrate' to rewrite it", path, version)
+	}
+
+	return decodeConfig(path, data)
+}
+
+// readConfigFile reads a config file in the current format, migrating an old
+// one, and returns the version the file is in
+func readConfigFile(path string) ([]byte, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+
+	version := configVersion(raw)
+	if version > ConfigVersion {
+		return nil, 0, fmt.Errorf("config %s has version %d, this Blink only understands up to %d", path, version, ConfigVersion)
+	}
+	if version < ConfigVersion {
+		if data, err = migrateConfig(raw, version); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return data, version, nil
+}
+
+// configMigrate rewrites an old config file in the current format, the
+// original is kept as config.toml.v<N>.bak. It returns the version migrated
+// from, ConfigVersion when there was nothing to do
+func configMigrate() (int, error) {
+	var version int
+	err := withLock(func() error {
+		data, from, err := readConfigFile(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+		if version = from; from == ConfigVersion {
+			return nil
+		}
+
+		// never write something we can't read back
+		if _, err := decodeConfig(ConfigFilePath, data); err != nil {
+			return fmt.Errorf("refusing to write config: %v", err)
+		}
+
+		old, err := os.ReadFile(ConfigFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %v", err)
+		}
+		backup := fmt.Sprintf("%s.v%d.bak", ConfigFilePath, from)
+		if err := os.WriteFile(backup, old, 0640); err != nil {
+			return fmt.Errorf("failed to back up config: %v", err)
+		}
+		if err := writeFileAtomic(ConfigFilePath, data, 0640); err != nil {
+			return err
+		}
+		eyes.Infof("Migrated %s to config version %d, the old config is kept as %s", ConfigFilePath, ConfigVersion, backup)
+		return nil
+	})
+	return version, err
+}
+
+// configVersion returns the schema version of a decoded config, configs from
+// before versioning only had repository tables and count as version 1
+func configVersion(raw map[string]any) int {
+	if v, ok := raw["version"].(int64); ok {
+		return int(v)
+	}
+	if _, ok := raw["repos"]; ok {
+		return ConfigVersion // written by hand without a version
+	}
+	if _, ok := raw["settings"]; ok {
+		return ConfigVersion
+	}
+	if len(raw) == 0 {
+		return ConfigVersion
+	}
+	return 1
+}
+
+// migrateConfig turns an old config into the current format
+func migrateConfig(raw map[string]any, version int) ([]byte, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("don't know how to migrate config version %d", version)
 	}
 
-	if _, err := toml.DecodeFile(path, &raw); err != nil {
-		return nil, fmt.Errorf("failed to decode repo config: %v", err)
+	// version 1: every top-level table is a repository, trustedKey was spelled two ways
+	repos := map[string]any{}
+	migrated := map[string]any{"version": ConfigVersion, "repos": repos}
+
+	for name, value := range raw {
+		table, ok := value.(map[string]any)
+		if !ok {
+			migrated[name] = value // not a repository, validation will complain about it
+			continue
+		}
+		if key, ok := table["trustedKey"]; ok {
+			table["trusted_key"] = key
+			delete(table, "trustedKey")
+		}
+		repos[name] = table
 	}
 
-	repos := make(map[string]RepoConfig)
-	for name, r := range raw {
-		repos[name] = RepoConfig{
-			Name:       name,
-			URL:        r.GitURL,
-			Ref:        r.Branch,
-			Hash:       r.Hash,
-			TrustedKey: r.Key,
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(migrated); err != nil {
+		return nil, fmt.Errorf("failed to migrate config: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeConfig strictly decodes a current config and validates it, every
+// problem is reported at once together with its line in the file
+func decodeConfig(path string, data []byte) (Config, error) {
+	var cfg Config
+
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+
+	lines := configKeyLines(data)
+	var problems []string
+
+	// only report the outermost unknown key, not every key below an unknown table
+	unknown := map[string]bool{}
+	for _, key := range md.Undecoded() {
+		unknown[key.String()] = true
+	}
+	for _, key := range md.Undecoded() {
+		if len(key) > 1 && unknown[key[:len(key)-1].String()] {
+			continue
 		}
+		problems = append(problems, configProblem(lines, key.String(), "unknown key"))
 	}
 
-	return repos, nil
+	problems = append(problems, validateConfig(cfg, lines)...)
+
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problemLine(problems[i]) < problemLine(problems[j])
+		})
+		return Config{}, fmt.Errorf("invalid config %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	if cfg.Version == 0 {
+		cfg.Version = ConfigVersion
+	}
+	if cfg.Repos == nil {
+		cfg.Repos = map[string]RepoConfig{}
+	}
+	if cfg.Packages == nil {
+		cfg.Packages = map[string]PackageConfig{}
+	}
+	// the table name is the repository name
+	for name, repo := range cfg.Repos {
+		repo.Name = name
+		cfg.Repos[name] = repo
+	}
+
+	return cfg, nil
+}
+
+// validateConfig checks the values of a decoded config
+func validateConfig(cfg Config, lines map[string]int) []string {
+	var problems []string
+	problem := func(key, format string, args ...any) {
+		problems = append(problems, configProblem(lines, key, fmt.Sprintf(format, args...)))
+	}
+
+	s := cfg.Settings
+	if s.CacheDir != "" && !filepath.IsAbs(s.CacheDir) {
+		problem("settings.cache_dir", "must be an absolute path")
+	}
+	if s.DefaultRoot != "" && !filepath.IsAbs(s.DefaultRoot) {
+		problem("settings.default_root", "must be an absolute path")
+	}
+	if s.Jobs < 0 {
+		problem("settings.jobs", "must not be negative")
+	}
+	if s.Parallelism < 0 {
+		problem("settings.parallelism", "must not be negative")
+	}
+	if _, err := settingDuration(s.SyncTimeout); err != nil {
+		problem("settings.sync_timeout", "%v", err)
+	}
+	if _, err := settingDuration(s.DownloadTimeout); err != nil {
+		problem("settings.download_timeout", "%v", err)
+	}
+	if s.ChecksumPolicy != "" && !validChecksumPolicy(s.ChecksumPolicy) {
+		problem("settings.checksum_policy", "must be %q or %q", ChecksumPolicyStrict, ChecksumPolicyPermissive)
+	}
+	if s.Proxy != "" {
+		if u, err := url.Parse(s.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			problem("settings.proxy", "invalid proxy url %q", s.Proxy)
+		}
+	}
+	profiles := mergeProfiles(cfg.Profiles)
+	knownProfile := func(key, name string) {
+		if _, ok := profiles[name]; name != "" && !ok {
+			problem(key, "unknown build profile %q (known: %s)", name, strings.Join(sortedKeys(profiles), ", "))
+		}
+	}
+	knownProfile("settings.profile", s.Profile)
+
+	for _, name := range sortedKeys(cfg.Profiles) {
+		key := "profiles." + name
+		if err := validProfileName(name); err != nil {
+			problem(key, "%v", err)
+		}
+		if cfg.Profiles[name].Jobs < 0 {
+			problem(key+".jobs", "must not be negative")
+		}
+	}
+	for _, name := range sortedKeys(cfg.Packages) {
+		knownProfile("packages."+name+".profile", cfg.Packages[name].Profile)
+	}
+
+	for _, name := range sortedRepoNames(cfg.Repos) {
+		repo := cfg.Repos[name]
+		key := "repos." + name
+
+		if err := validRepoName(name); err != nil {
+			problem(key, "%v", err)
+		}
+
+		switch repo.Kind() {
+		case RepoTypeGit:
+			if repo.URL == "" {
+				problem(key, "git repository needs git_url")
+			}
+		case RepoTypeLocal:
+			if !filepath.IsAbs(repo.Path) {
+				problem(key+".path", "local repository needs an absolute path")
+			}
+		case RepoTypeHTTP:
+			if repo.BaseURL == "" {
+				problem(key, "http repository needs url")
+			}
+		default:
+			problem(key+".type", "unknown repository type %q (expected %s, %s or %s)", repo.Type, RepoTypeGit, RepoTypeLocal, RepoTypeHTTP)
+		}
+
+		if _, err := parseExpiry(repo.Expiry); err != nil {
+			problem(key+".expiry", "%v", err)
+		}
+	}
+
+	return problems
+}
+
+// configProblem formats a problem with the line of key, when known
+func configProblem(lines map[string]int, key, msg string) string {
+	if line, ok := lines[key]; ok {
+		return fmt.Sprintf("line %d: %s: %s", line, key, msg)
+	}
+	return fmt.Sprintf("%s: %s", key, msg)
+}
+
+// problemLine returns the line a problem was reported on, problems without a line sort last
+func problemLine(problem string) int {
+	var line int
+	if _, err := fmt.Sscanf(problem, "line %d:", &line); err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return line
+}
+
+// configKeyLines maps every table and key of a TOML file to the line it is defined
+// on. The toml package doesn't expose positions, a simple scan is enough for
+// the flat files Blink writes and the ones people write by hand.
+func configKeyLines(data []byte) map[string]int {
+	lines := map[string]int{}
+	table := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, "["):
+			header := strings.Trim(line, "[] \t")
+			if i := strings.Index(header, "#"); i >= 0 {
+				header = strings.Trim(header[:i], "[] \t")
+			}
+			table = normalizeConfigKey(header)
+			if _, ok := lines[table]; !ok {
+				lines[table] = n
+			}
+
+		default:
+			name, _, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			key := normalizeConfigKey(name)
+			if table != "" {
+				key = table + "." + key
+			}
+			if _, ok := lines[key]; !ok {
+				lines[key] = n
+			}
+		}
+	}
+
+	return lines
+}
+
+// normalizeConfigKey strips spaces around the dots of a dotted key
+func normalizeConfigKey(key string) string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// saveConfig validates cfg and writes it to ConfigFilePath, keeping the
+// previous file as config.toml.bak. Comments are not preserved.
+func saveConfig(cfg Config) error {
+	cfg.Version = ConfigVersion
+
+	problems := validateConfig(cfg, nil)
+	// builds look the user up when they start, loading the config doesn't
+	if _, err := cfg.Settings.BuildCredential(); err != nil {
+		problems = append(problems, fmt.Sprintf("settings.build_user: %v", err))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = "" // same flat layout as the default config
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+
+	// never write something we can't read back
+	if _, err := decodeConfig(ConfigFilePath, buf.Bytes()); err != nil {
+		return fmt.Errorf("refusing to write config: %v", err)
+	}
+
+	if old, err := os.ReadFile(ConfigFilePath); err == nil {
+		if err := os.WriteFile(ConfigFilePath+".bak", old, 0640); err != nil {
+			return fmt.Errorf("failed to back up config: %v", err)
+		}
+	}
+
+	return writeFileAtomic(ConfigFilePath, buf.Bytes(), 0640)
+}
+
+// writeFileAtomic writes data next to path and renames it into place
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// hostDefaultRoot reads default_root from the host's config, it becomes the
+// default of --root. Any problem just means there is no configured default.
+func hostDefaultRoot() string {
+	var cfg struct {
+		Settings struct {
+			DefaultRoot string `toml:"default_root"`
+		} `toml:"settings"`
+	}
+
+	if _, err := toml.DecodeFile(ComputePaths("/").ConfigFile, &cfg); err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(cfg.Settings.DefaultRoot) {
+		return ""
+	}
+	return cfg.Settings.DefaultRoot
+}
+
+//===================================================================//
+//							 blink config
+//===================================================================//
+
+// configField finds the field behind a dotted config key ("jobs", "settings.jobs",
+// "repos.<name>.branch", "profiles.<name>.cflags" or "packages.<name>.profile").
+// Tables are map values which can't be changed in place, for them the field
+// belongs to a copy which is returned as well. create lets a key of a profile
+// or package that has no table yet start one.
+func configField(cfg *Config, key string, create bool) (reflect.Value, reflect.Value, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) == 1 {
+		parts = []string{"settings", parts[0]}
+	}
+
+	switch {
+	case parts[0] == "settings" && len(parts) == 2:
+		field, ok := fieldByTag(reflect.ValueOf(&cfg.Settings).Elem(), parts[1])
+		if !ok {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("unknown setting %q", parts[1])
+		}
+		return field, reflect.Value{}, nil
+
+	case parts[0] == "repos" && len(parts) == 3:
+		repo, ok := cfg.Repos[parts[1]]
+		if !ok {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("repository %s is not configured (add it with 'blink repo add')", parts[1])
+		}
+		editable := reflect.New(reflect.TypeOf(repo)).Elem()
+		editable.Set(reflect.ValueOf(repo))
+		field, ok := fieldByTag(editable, parts[2])
+		if !ok {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("unknown repository key %q", parts[2])
+		}
+		return field, editable, nil
+
+	case parts[0] == "profiles" && len(parts) == 3:
+		if err := validProfileName(parts[1]); err != nil {
+			return reflect.Value{}, reflect.Value{}, err
+		}
+		// a built-in profile is changed by copying it into the config
+		profile, ok := mergeProfiles(cfg.Profiles)[parts[1]]
+		if !ok && !create {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("build profile %s is not defined", parts[1])
+		}
+		editable := reflect.New(reflect.TypeOf(profile)).Elem()
+		editable.Set(reflect.ValueOf(profile))
+		field, ok := fieldByTag(editable, parts[2])
+		if !ok {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("unknown profile key %q", parts[2])
+		}
+		return field, editable, nil
+
+	case parts[0] == "packages" && len(parts) == 3:
+		pkg := cfg.Packages[parts[1]]
+		editable := reflect.New(reflect.TypeOf(pkg)).Elem()
+		editable.Set(reflect.ValueOf(pkg))
+		field, ok := fieldByTag(editable, parts[2])
+		if !ok {
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("unknown package key %q", parts[2])
+		}
+		return field, editable, nil
+
+	default:
+		return reflect.Value{}, reflect.Value{}, fmt.Errorf("unknown config key %q (use <setting>, settings.<setting>, repos.<name>.<key>, profiles.<name>.<key> or packages.<name>.<key>)", key)
+	}
+}
+
+// fieldByTag returns the struct field with the given toml key
+func fieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if tag == name && tag != "-" {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// formatConfigValue shows a config value the way it's written in TOML
+func formatConfigValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = strconv.Quote(v.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// setConfigValue parses value into a config field, lists are comma separated
+func setConfigValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config value type %s", v.Kind())
+	}
+	return nil
+}
+
+// configGet returns the effective value of a config key
+func configGet(key string) (string, error) {
+	cfg, err := loadConfigFile(ConfigFilePath)
+	if err != nil {
+		return "", err
+	}
+	cfg.Settings = cfg.Settings.withDefaults()
+
+	field, _, err := configField(&cfg, key, false)
+	if err != nil {
+		return "", err
+	}
+	return formatConfigValue(field), nil
+}
+
+// configSet changes a config key and rewrites the config file
+func configSet(key, value string) error {
+	return withLock(func() error {
+		cfg, err := loadConfigFile(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+
+		field, table, err := configField(&cfg, key, true)
+		if err != nil {
+			return err
+		}
+		if err := setConfigValue(field, value); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		if table.IsValid() {
+			name := strings.Split(key, ".")[1]
+			switch edited := table.Interface().(type) {
+			case RepoConfig:
+				cfg.Repos[name] = edited
+			case BuildProfile:
+				if cfg.Profiles == nil {
+					cfg.Profiles = map[string]BuildProfile{}
+				}
+				cfg.Profiles[name] = edited
+			case PackageConfig:
+				cfg.Packages[name] = edited
+			}
+		}
+
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		eyes.Infof("Set %s = %s", key, formatConfigValue(field))
+		return nil
+	})
+}
+
+// configShow prints the effective config, defaults included
+func configShow() error {
+	cfg, err := loadConfigFile(ConfigFilePath)
+	if err != nil {
+		return err
+	}
+	cfg.Settings = cfg.Settings.withDefaults()
+
+	if machineOutput() {
+		return emit(configOutput(cfg))
+	}
+
+	fmt.Printf("# %s\nversion = %d\n\n[settings]\n", ConfigFilePath, cfg.Version)
+
+	// every setting, even the empty ones, so this doubles as a reference
+	v := reflect.ValueOf(cfg.Settings)
+	for i := 0; i < v.NumField(); i++ {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("toml"), ",")
+		fmt.Printf("%s = %s\n", tag, formatConfigValue(v.Field(i)))
+	}
+	fmt.Println()
+
+	// built-in profiles too, they can be used without being configured
+	enc := toml.NewEncoder(os.Stdout)
+	enc.Indent = ""
+	return enc.Encode(struct {
+		Repos    map[string]RepoConfig    `toml:"repos"`
+		Profiles map[string]BuildProfile  `toml:"profiles"`
+		Packages map[string]PackageConfig `toml:"packages,omitempty"`
+	}{cfg.Repos, mergeProfiles(cfg.Profiles), cfg.Packages})
+}
+
+// configOutput turns a config into a document keyed like config.toml, for --output
+func configOutput(cfg Config) map[string]any {
+	settings := map[string]any{}
+	v := reflect.ValueOf(cfg.Settings)
+	for i := 0; i < v.NumField(); i++ {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("toml"), ",")
+		settings[tag] = v.Field(i).Interface()
+	}
+
+	return map[string]any{
+		"version":  cfg.Version,
+		"settings": settings,
+		"repos":    configTables(cfg.Repos),
+		"profiles": configTables(mergeProfiles(cfg.Profiles)),
+		"packages": configTables(cfg.Packages),
+	}
+}
+
+// configTables turns [<kind>.<name>] tables into documents, they go through
+// TOML so they keep their config.toml keys
+func configTables[T any](tables map[string]T) map[string]any {
+	out := map[string]any{}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(struct {
+		Tables map[string]T `toml:"t"`
+	}{tables}); err == nil {
+		var decoded struct {
+			Tables map[string]any `toml:"t"`
+		}
+		if _, err := toml.Decode(buf.String(), &decoded); err == nil && decoded.Tables != nil {
+			out = decoded.Tables
+		}
+	}
+	return out
+}
+
+// Kind returns the repository backend type, repositories without
+// a type are git repositories like they have always been
+func (r RepoConfig) Kind() string {
+	if r.Type == "" {
+		return RepoTypeGit
+	}
+	return strings.ToLower(r.Type)
+}
+
+// Location returns where the repository comes from, for display
+func (r RepoConfig) Location() string {
+	switch r.Kind() {
+	case RepoTypeLocal:
+		return r.Path
+	case RepoTypeHTTP:
+		return r.BaseURL
+	default:
+		return r.URL
+	}
 }
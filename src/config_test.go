@@ -0,0 +1,171 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	const v1 = `
+[main]
+git_url = "https://example.com/repo.git"
+branch = "main"
+trustedKey = "keys/main.asc"
+
+[overlay]
+git_url = "https://example.com/overlay.git"
+trusted_key = "keys/overlay.asc"
+priority = 5
+`
+	var raw map[string]any
+	if _, err := toml.Decode(v1, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if version := configVersion(raw); version != 1 {
+		t.Fatalf("configVersion = %d, want 1", version)
+	}
+
+	migrated, err := migrateConfig(raw, 1)
+	if err != nil {
+		t.Fatalf("migrateConfig: %v", err)
+	}
+	cfg, err := decodeConfig("config.toml", migrated)
+	if err != nil {
+		t.Fatalf("decodeConfig of the migrated config: %v\n%s", err, migrated)
+	}
+
+	if cfg.Version != ConfigVersion {
+		t.Errorf("version = %d, want %d", cfg.Version, ConfigVersion)
+	}
+	want := map[string]RepoConfig{
+		"main":    {Name: "main", URL: "https://example.com/repo.git", Ref: "main", TrustedKey: "keys/main.asc"},
+		"overlay": {Name: "overlay", URL: "https://example.com/overlay.git", TrustedKey: "keys/overlay.asc", Priority: 5},
+	}
+	for name, repo := range want {
+		if got := cfg.Repos[name]; got.Name != repo.Name || got.URL != repo.URL || got.Ref != repo.Ref ||
+			got.TrustedKey != repo.TrustedKey || got.Priority != repo.Priority {
+			t.Errorf("repos.%s = %+v, want %+v", name, got, repo)
+		}
+	}
+	if len(cfg.Repos) != len(want) {
+		t.Errorf("repos = %v, want %d of them", sortedRepoNames(cfg.Repos), len(want))
+	}
+
+	// reading the migrated config again needs no migration
+	raw = nil
+	if _, err := toml.Decode(string(migrated), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if version := configVersion(raw); version != ConfigVersion {
+		t.Errorf("configVersion of the migrated config = %d, want %d", version, ConfigVersion)
+	}
+
+	if _, err := migrateConfig(map[string]any{}, ConfigVersion+1); err == nil {
+		t.Errorf("migrateConfig of version %d succeeded, want an error", ConfigVersion+1)
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string // problems in order, "" for a config without any
+	}{
+		{
+			name: "valid",
+			config: `version = 2
+[settings]
+jobs = 4
+checksum_policy = "permissive"
+
+[repos.main]
+git_url = "https://example.com/repo.git"
+`,
+		},
+		{
+			name: "unknown keys",
+			config: `version = 2
+[settings]
+jbos = 4
+
+[repos.main]
+git_url = "https://example.com/repo.git"
+brnach = "main"
+`,
+			want: []string{"line 3: settings.jbos: unknown key", "line 7: repos.main.brnach: unknown key"},
+		},
+		{
+			name: "only the unknown table, not its keys",
+			config: `version = 2
+[setings]
+jobs = 4
+proxy = "http://proxy:3128"
+`,
+			want: []string{"line 2: setings: unknown key"},
+		},
+		{
+			name: "invalid values sorted by line",
+			config: `version = 2
+[settings]
+checksum_policy = "lax"
+jobs = -1
+
+[repos.local]
+type = "local"
+path = "relative/dir"
+`,
+			want: []string{
+				"line 3: settings.checksum_policy: must be",
+				"line 4: settings.jobs: must not be negative",
+				"line 8: repos.local.path: local repository needs an absolute path",
+			},
+		},
+	}
+	for _, tt := range tests {
+		_, err := decodeConfig("config.toml", []byte(tt.config))
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: no error, want %q", tt.name, tt.want)
+			continue
+		}
+
+		// the first line names the file, then one problem per line
+		lines := strings.Split(err.Error(), "\n")[1:]
+		if len(lines) != len(tt.want) {
+			t.Errorf("%s: error = %v, want %d problems", tt.name, err, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if got := strings.TrimSpace(lines[i]); !strings.HasPrefix(got, want) {
+				t.Errorf("%s: problem %d = %q, want %q", tt.name, i, got, want)
+			}
+		}
+	}
+}
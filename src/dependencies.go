@@ -33,13 +33,22 @@ import (
 // have fun maintaining if youre a maintainer :D
 // TIP: Check out github.com/Aperture-OS/togosort-dfs docs and comments in source code
 
-// Recursive helper to build dependency graph
+// depRequirement is the version constraint one package puts on a dependency
+type depRequirement struct {
+	By         string // package declaring the dependency
+	Constraint string // eg. ">=1.0.0"
+}
+
+// Recursive helper to build dependency graph, every constraint found
+// along the way is collected in requires (keyed by dependency)
 // IMPORTANT: AddEdge(A, B) == A depends on B
 func buildDepGraph(
 	graph *togosort.Graph,
 	pkgName string,
 	path string,
+	repos map[string]RepoConfig,
 	visited map[string]bool,
+	requires map[string][]depRequirement,
 ) error {
 	if visited[pkgName] {
 		return nil
@@ -51,11 +60,17 @@ func buildDepGraph(
 		return fmt.Errorf("failed to fetch package %s: %v", pkgName, err)
 	}
 
-	for dep := range pkg.Dependencies {
+	for dep, constraint := range pkg.Dependencies {
+		dep, err := depNode(dep, repos)
+		if err != nil {
+			return fmt.Errorf("%s depends on %v", pkgName, err)
+		}
+
 		// pkgName depends on dep
 		graph.AddEdge(pkgName, dep)
+		requires[dep] = append(requires[dep], depRequirement{By: pkgName, Constraint: constraint})
 
-		if err := buildDepGraph(graph, dep, path, visited); err != nil {
+		if err := buildDepGraph(graph, dep, path, repos, visited, requires); err != nil {
 			return err
 		}
 	}
@@ -63,15 +78,110 @@ func buildDepGraph(
 	return nil
 }
 
-// Handle mandatory dependencies (DFS + topo)
-func handleMandatoryDeps(pkgName, path string) error {
-	graph := togosort.NewGraph()
-	visited := make(map[string]bool)
+// depNode names a dependency <repo>/<name> after the repository it resolves
+// to, so "x" and "repo/x" are one node of the graph and not two packages
+func depNode(dep string, repos map[string]RepoConfig) (string, error) {
+	repo, _, err := FindRepoForPackage(dep, repos)
+	if err != nil {
+		return "", err
+	}
+	_, name := splitQualifiedName(dep)
+	return qualifiedName(repo.Name, name), nil
+}
+
+// checkDepVersions makes sure every dependency in order satisfies the constraints
+// put on it: the installed version if it's installed, the repository's otherwise
+func checkDepVersions(order []string, requires map[string][]depRequirement, path string) error {
+	for _, dep := range order {
+		reqs := requires[dep]
+		if len(reqs) == 0 {
+			continue
+		}
+
+		var version Version
+		source := "available"
+
+		_, name := splitQualifiedName(dep)
+		if installed, ok, err := manifestHas(name); err != nil {
+			return err
+		} else if ok {
+			version = pkgVersion(installed.Version, int(installed.Release))
+			source = "installed"
+		} else {
+			pkg, err := fetchpkg(path, false, dep, true)
+			if err != nil {
+				return fmt.Errorf("failed to fetch package %s: %v", dep, err)
+			}
+			version = pkgVersion(pkg.Version, pkg.Release)
+		}
 
-	if err := buildDepGraph(graph, pkgName, path, visited); err != nil {
+		for _, req := range reqs {
+			constraints, err := ParseConstraints(req.Constraint)
+			if err != nil {
+				return fmt.Errorf("%s depends on %s: %v", req.By, dep, err)
+			}
+			if !constraintsAllow(constraints, version) {
+				return fmt.Errorf("%s requires %s %s but the %s version is %s",
+					req.By, dep, req.Constraint, source, version)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Handle mandatory dependencies of pkg (DFS + topo), the missing ones are
+// installed with reason, ReasonDependency or ReasonBuild
+func handleMandatoryDeps(pkg PackageInfo, path, reason string) error {
+	return resolveDeps(qualifiedName(pkg.Repo, pkg.Name), "mandatory", path, reason, pkg.Dependencies)
+}
+
+// handleBuildDeps installs what pkg needs to be built, build dependencies and
+// the check dependencies when its checks run, as ReasonBuild. Packages that
+// are installed already keep their reason
+func handleBuildDeps(pkg PackageInfo, path string) error {
+	lists := []map[string]string{pkg.BuildDependencies}
+	n := len(pkg.BuildDependencies)
+	if checksEnabled(pkg.Name) {
+		lists = append(lists, pkg.CheckDependencies)
+		n += len(pkg.CheckDependencies)
+	}
+	if n == 0 {
+		return nil
+	}
+	return resolveDeps(qualifiedName(pkg.Repo, pkg.Name), "build", path, ReasonBuild, lists...)
+}
+
+// resolveDeps installs the missing dependencies of pkgName in lists, together
+// with everything they depend on at runtime. A dependency in several lists has
+// to satisfy all of their constraints. kind names them in messages ("mandatory", "build")
+func resolveDeps(pkgName, kind, path, reason string, lists ...map[string]string) error {
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
 		return err
 	}
 
+	graph := togosort.NewGraph()
+	visited := map[string]bool{pkgName: true}
+	requires := make(map[string][]depRequirement)
+
+	for _, deps := range lists {
+		for dep, constraint := range deps {
+			dep, err := depNode(dep, repos)
+			if err != nil {
+				return fmt.Errorf("%s depends on %v", pkgName, err)
+			}
+
+			// pkgName depends on dep
+			graph.AddEdge(pkgName, dep)
+			requires[dep] = append(requires[dep], depRequirement{By: pkgName, Constraint: constraint})
+
+			if err := buildDepGraph(graph, dep, path, repos, visited, requires); err != nil {
+				return err
+			}
+		}
+	}
+
 	// cycle detection
 	if err := graph.DFS([]string{pkgName}); err != nil {
 		return fmt.Errorf("dependency cycle detected: %v", err)
@@ -79,6 +189,10 @@ func handleMandatoryDeps(pkgName, path string) error {
 
 	order := graph.TopoSort()
 
+	if err := checkDepVersions(order, requires, path); err != nil {
+		return err
+	}
+
 	var missing []string
 	for _, dep := range order {
 		if dep == pkgName {
@@ -95,9 +209,13 @@ func handleMandatoryDeps(pkgName, path string) error {
 		return nil
 	}
 
-	eyes.Warnf("Missing mandatory dependencies: %v", missing)
-	eyes.Warnf("Mandatory dependencies are required for proper functionality.")
-	eyes.Warn("Do you want to install mandatory dependencies? [ (Y)es / (N)o ]: ")
+	eyes.Warnf("Missing %s dependencies: %v", kind, missing)
+	if kind == "build" {
+		eyes.Warnf("Build dependencies are required to build %s.", pkgName)
+	} else {
+		eyes.Warnf("Mandatory dependencies are required for proper functionality.")
+	}
+	eyes.Warnf("Do you want to install %s dependencies? [ (Y)es / (N)o ]: ", kind)
 
 	var input string
 	fmt.Scanln(&input)
@@ -105,7 +223,7 @@ func handleMandatoryDeps(pkgName, path string) error {
 
 	switch input {
 	case "n", "no":
-		eyes.Fatalf("Cannot continue without mandatory dependencies.")
+		fatalf("Cannot continue without %s dependencies.", kind)
 	case "bypass-donotuse":
 		eyes.Warnf(`[DEVELOPER ONLY/INSECURE] Bypassing mandatory dependencies check (press CTRL+C to cancel).
 This is not secure, your package could break! To fix this properly rerun the command you just ran and install the missing dependencies
@@ -118,22 +236,17 @@ our Discord server. Run 'blink support' for more informations.`)
 		if dep == pkgName || isInstalled(dep) {
 			continue
 		}
-		eyes.Infof("Installing dependency %s", dep)
-		if err := install(dep, false, path); err != nil {
-			return fmt.Errorf("failed to install dependency %s: %v", dep, err)
+		eyes.Infof("Installing %s dependency %s", kind, dep)
+		if err := install(dep, false, path, reason); err != nil {
+			return fmt.Errorf("failed to install %s dependency %s: %v", kind, dep, err)
 		}
 	}
 
 	return nil
 }
 
-// Handle optional dependencies (DFS + topo per choice)
-func handleOptionalDeps(pkgName string, path string) error {
-	pkg, err := fetchpkg(path, false, pkgName, true)
-	if err != nil {
-		return fmt.Errorf("failed to fetch package %s: %v", pkgName, err)
-	}
-
+// Handle optional dependencies of pkg (DFS + topo per choice), installed with reason
+func handleOptionalDeps(pkg PackageInfo, path, reason string) error {
 	for _, group := range pkg.OptDeps {
 		var installed []string
 		var notInstalled []string
@@ -182,12 +295,20 @@ func handleOptionalDeps(pkgName string, path string) error {
 			continue
 		}
 
-		selected := notInstalled[choice-1]
+		repos, err := LoadRepos(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+		selected, err := depNode(notInstalled[choice-1], repos)
+		if err != nil {
+			return fmt.Errorf("%s optionally depends on %v", pkg.Name, err)
+		}
 
 		graph := togosort.NewGraph()
 		visited := make(map[string]bool)
+		requires := make(map[string][]depRequirement)
 
-		if err := buildDepGraph(graph, selected, path, visited); err != nil {
+		if err := buildDepGraph(graph, selected, path, repos, visited, requires); err != nil {
 			return err
 		}
 
@@ -197,12 +318,16 @@ func handleOptionalDeps(pkgName string, path string) error {
 
 		order := graph.TopoSort()
 
+		if err := checkDepVersions(order, requires, path); err != nil {
+			return err
+		}
+
 		for _, dep := range order {
 			if isInstalled(dep) {
 				continue
 			}
 			eyes.Infof("Installing optional dependency %s", dep)
-			if err := install(dep, false, path); err != nil {
+			if err := install(dep, false, path, reason); err != nil {
 				return fmt.Errorf("failed to install optional dependency %s: %v", dep, err)
 			}
 		}
@@ -210,3 +335,42 @@ func handleOptionalDeps(pkgName string, path string) error {
 
 	return nil
 }
+
+// removeBuildDeps uninstalls the build-only packages installed since before,
+// the state at the start of the transaction, as soon as nothing installed needs
+// them at runtime. Build dependencies that were there before stay. It returns
+// the removed packages
+func removeBuildDeps(before Manifest, path string) ([]string, error) {
+	had := make(map[string]bool, len(before.Installed))
+	for _, p := range before.Installed {
+		had[p.Name] = true
+	}
+
+	removed := []string{}
+	for {
+		m, err := loadManifest()
+		if err != nil {
+			return removed, err
+		}
+
+		// removing a build dependency can orphan what it needed, so go round until nothing is left
+		orphans := findOrphans(m)
+		var unneeded []string
+		for _, p := range m.Installed {
+			if p.Reason == ReasonBuild && orphans[p.Name] && !had[p.Name] {
+				unneeded = append(unneeded, p.Name)
+			}
+		}
+		if len(unneeded) == 0 {
+			return removed, nil
+		}
+
+		for _, name := range unneeded {
+			eyes.Infof("Removing build dependency %s", name)
+			if err := uninstall(name, false, path); err != nil {
+				return removed, fmt.Errorf("failed to remove build dependency %s: %v", name, err)
+			}
+			removed = append(removed, name)
+		}
+	}
+}
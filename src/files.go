@@ -0,0 +1,270 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Installed files. Install steps don't write to the root directly, they install
+// into a staging directory (${destdir}) that Blink copies to the root, so every
+// file a package installs is known. The list is kept in the state dir and is
+// what `blink uninstall` removes, and what a reinstall compares against to
+// remove the files the new version doesn't have anymore.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// stagingDir is ${destdir} of a package, outside of its build dir since that
+// belongs to build_user while what's staged is installed as root
+func stagingDir(pkgName string) string {
+	return filepath.Join(BuildDirPath, ".destdir", pkgName)
+}
+
+// fileListPath is where the files of an installed package are listed
+func fileListPath(pkgName string) string {
+	return filepath.Join(StateDirPath, "files", pkgName+".list")
+}
+
+// installStaged copies the staged tree to root and returns what it installed,
+// paths relative to root with directories ending in a /, and the size of the
+// regular files. Existing directories are left as they are, files and symlinks
+// are replaced
+func installStaged(staging, root string) ([]string, int64, error) {
+	var files []string
+	var size int64
+	err := filepath.Walk(staging, func(src string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, src)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if strings.Contains(rel, "\n") {
+			return fmt.Errorf("can't install %q, file names with a newline aren't supported", rel)
+		}
+		target := filepath.Join(root, rel)
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			if existing, err := os.Stat(target); err == nil {
+				if !existing.IsDir() {
+					return fmt.Errorf("can't install directory %s, a file is in the way", target)
+				}
+			} else if err := os.Mkdir(target, mode.Perm()); err != nil {
+				return err
+			} else if err := copyAttrs(target, info); err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel)+"/")
+			return nil
+
+		case mode&os.ModeSymlink != 0:
+			dest, err := os.Readlink(src)
+			if err != nil {
+				return err
+			}
+			if err := replaceFile(target, func(tmp string) error { return os.Symlink(dest, tmp) }, info); err != nil {
+				return err
+			}
+
+		case mode.IsRegular():
+			err := replaceFile(target, func(tmp string) error { return copyFile(src, tmp, mode.Perm()) }, info)
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+
+		default:
+			eyes.Warnf("Skipping %s, only files, directories and symlinks are installed", src)
+			return nil
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, size, err
+}
+
+// replaceFile creates a file next to target with create and renames it over
+// target, a running program being replaced keeps its old copy
+func replaceFile(target string, create func(tmp string) error, info os.FileInfo) error {
+	if existing, err := os.Lstat(target); err == nil && existing.IsDir() {
+		return fmt.Errorf("can't install %s, a directory is in the way", target)
+	}
+
+	tmp := filepath.Join(filepath.Dir(target), ".blink-new."+filepath.Base(target))
+	os.Remove(tmp)
+	if err := create(tmp); err != nil {
+		return err
+	}
+	if err := copyAttrs(tmp, info); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// copyFile copies a regular file's content
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyAttrs gives path the owner and mode of info, the mode after the owner
+// since changing the owner drops the setuid and setgid bits
+func copyAttrs(path string, info os.FileInfo) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(path, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil // a symlink's own mode means nothing
+	}
+	return os.Chmod(path, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
+
+// removeFiles removes listed files from root, then the listed directories
+// that are empty afterwards, deepest first. keep are files that stay, what a
+// newer version of the package installed too
+func removeFiles(root string, files []string, keep map[string]bool) error {
+	var dirs []string
+	for _, f := range files {
+		if keep[f] {
+			continue
+		}
+		if strings.HasSuffix(f, "/") {
+			dirs = append(dirs, f)
+			continue
+		}
+		if err := os.Remove(filepath.Join(root, filepath.FromSlash(f))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs))) // a directory sorts before what's in it
+	for _, d := range dirs {
+		// other packages' files and what the system added later keep a directory
+		os.Remove(filepath.Join(root, filepath.FromSlash(d)))
+	}
+	return nil
+}
+
+// readFileList returns the installed files of a package, false when Blink
+// didn't record them (installed by an older Blink)
+func readFileList(pkgName string) ([]string, bool, error) {
+	f, err := os.Open(fileListPath(pkgName))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			files = append(files, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read file list of %s: %v", pkgName, err)
+	}
+	return files, true, nil
+}
+
+// writeFileList records the installed files of a package
+func writeFileList(pkgName string, files []string) error {
+	path := fileListPath(pkgName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f)
+		b.WriteByte('\n')
+	}
+	return writeFileAtomic(path, []byte(b.String()), 0644)
+}
+
+// removeFileList forgets the installed files of a package
+func removeFileList(pkgName string) error {
+	if err := os.Remove(fileListPath(pkgName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// installFiles installs the staged tree of pkgName to RootDirPath and
+// replaces its file list, it returns the files and their size. Files of the
+// installed version that the new one doesn't have anymore are removed
+func installFiles(pkgName, staging string) ([]string, int64, error) {
+	old, _, err := readFileList(pkgName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	files, size, err := installStaged(staging, RootDirPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to install %s: %v", pkgName, err)
+	}
+	if err := writeFileList(pkgName, files); err != nil {
+		return nil, 0, err
+	}
+
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		keep[f] = true
+	}
+	if err := removeFiles(RootDirPath, old, keep); err != nil {
+		eyes.Warnf("Failed to remove old files of %s: %v", pkgName, err)
+	}
+	return files, size, nil
+}
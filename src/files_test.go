@@ -0,0 +1,97 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInstallStaged(t *testing.T) {
+	staging, root := t.TempDir(), t.TempDir()
+	write := func(path, content string, perm os.FileMode) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(staging, "usr/bin/foo"), "#!/bin/sh\n", 0755)
+	write(filepath.Join(staging, "usr/share/foo/data"), "data", 0644)
+	if err := os.Symlink("foo", filepath.Join(staging, "usr/bin/foo-link")); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(root, "usr/bin/foo"), "old version", 0644) // replaced
+	write(filepath.Join(root, "usr/bin/other"), "other package", 0755)
+
+	files, size, err := installStaged(staging, root)
+	if err != nil {
+		t.Fatalf("installStaged: %v", err)
+	}
+	want := []string{"usr/", "usr/bin/", "usr/bin/foo", "usr/bin/foo-link", "usr/share/", "usr/share/foo/", "usr/share/foo/data"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %q, want %q", files, want)
+	}
+	if size != int64(len("#!/bin/sh\n")+len("data")) {
+		t.Errorf("size = %d, want %d", size, len("#!/bin/sh\n")+len("data"))
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(root, "usr/bin/foo")); string(data) != "#!/bin/sh\n" {
+		t.Errorf("usr/bin/foo = %q, want the staged file", data)
+	}
+	if info, err := os.Stat(filepath.Join(root, "usr/bin/foo")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("usr/bin/foo mode = %v (%v), want 0755", info.Mode(), err)
+	}
+	if dest, err := os.Readlink(filepath.Join(root, "usr/bin/foo-link")); err != nil || dest != "foo" {
+		t.Errorf("usr/bin/foo-link -> %q (%v), want foo", dest, err)
+	}
+
+	// a newer version still has usr/bin/foo, the rest goes
+	if err := removeFiles(root, files, map[string]bool{"usr/": true, "usr/bin/": true, "usr/bin/foo": true}); err != nil {
+		t.Fatalf("removeFiles: %v", err)
+	}
+	for _, gone := range []string{"usr/bin/foo-link", "usr/share"} {
+		if _, err := os.Lstat(filepath.Join(root, gone)); !os.IsNotExist(err) {
+			t.Errorf("%s is still there (%v)", gone, err)
+		}
+	}
+	for _, kept := range []string{"usr/bin/foo", "usr/bin/other"} {
+		if _, err := os.Lstat(filepath.Join(root, kept)); err != nil {
+			t.Errorf("%s is gone: %v", kept, err)
+		}
+	}
+
+	// uninstalling keeps directories other files are still in
+	if err := removeFiles(root, files, nil); err != nil {
+		t.Fatalf("removeFiles: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "usr/bin/other")); err != nil {
+		t.Errorf("usr/bin/other is gone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "usr/bin/foo")); !os.IsNotExist(err) {
+		t.Errorf("usr/bin/foo is still there (%v)", err)
+	}
+}
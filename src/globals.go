@@ -37,6 +37,9 @@ type Paths struct {
 	RecipeDir    string
 	ManifestFile string
 	BuildDir     string
+	KeyringDir   string
+	StateDir     string
+	LogDir       string
 }
 
 // ComputePaths computes all paths based on a root directory
@@ -52,6 +55,9 @@ func ComputePaths(osRoot string) Paths {
 		RecipeDir:    filepath.Join(baseDataDir, "recipes"),
 		ManifestFile: filepath.Join(baseDataDir, "etc", "manifest.toml"),
 		BuildDir:     filepath.Join(baseDataDir, "build"),
+		KeyringDir:   filepath.Join(baseDataDir, "etc", "keys"),
+		StateDir:     filepath.Join(baseDataDir, "state"),
+		LogDir:       filepath.Join(baseDataDir, "log"),
 	}
 }
 
@@ -81,6 +87,9 @@ func ApplyRoot(osRoot string) error {
 		paths.RecipeDir,
 		paths.SourceDir,
 		paths.BuildDir,
+		paths.KeyringDir,
+		paths.StateDir,
+		paths.LogDir,
 	}
 	for _, dir := range subdirs {
 		if err := os.MkdirAll(dir, 0750); err != nil {
@@ -89,6 +98,7 @@ func ApplyRoot(osRoot string) error {
 	}
 
 	// Apply globals
+	RootDirPath = cleaned
 	BaseDataDirPath = paths.BaseDataDir
 	ConfigFilePath = paths.ConfigFile
 	LockFilePath = paths.LockFile
@@ -97,6 +107,12 @@ func ApplyRoot(osRoot string) error {
 	RecipeDirPath = paths.RecipeDir
 	ManifestFilePath = paths.ManifestFile
 	BuildDirPath = paths.BuildDir
+	KeyringDirPath = paths.KeyringDir
+	StateDirPath = paths.StateDir
+	LogDirPath = paths.LogDir
+	RepoStateFilePath = filepath.Join(paths.StateDir, "repos.toml")
+	IndexFilePath = filepath.Join(paths.StateDir, "index.json")
+	HoldsFilePath = filepath.Join(paths.StateDir, "holds.toml")
 
 	lock = &Lock{Path: LockFilePath}
 
@@ -118,14 +134,66 @@ var (
 	CurrentYear         = time.Now().Year()                               // Current year for copyright
 	CurrentBlinkVersion = "v0.2.0-alpha"                                  // Blink version
 
-	DefaultRepositoryList = `
-[pseudoRepository]
+	DefaultConfig = `# Blink configuration, run 'blink config show' to see every setting
+version = 2
+
+[settings]
+# cache_dir = "/var/cache/blink"  # where sources are downloaded and built
+# jobs = 0                         # parallel build jobs, 0 means one per CPU
+# build_user = "nobody"            # unprivileged user running the build steps
+# cflags = "-O2 -pipe"
+# ldflags = ""
+# profile = "release"              # build profile, see [profiles.<name>] below
+# proxy = "http://proxy.example.com:3128"
+# default_root = "/"
+# parallelism = 4                  # repositories synced at the same time
+# sync_timeout = "10m"
+# download_timeout = "30m"
+checksum_policy = "strict"
+# remove_build_deps = false        # uninstall build-only dependencies after installing
+# check = false                    # run test suites before installing, like --check
+
+[repos.pseudoRepository]
 git_url = "https://github.com/Aperture-OS/testing-blink-repo.git"
 branch = "main"
+
+# Build profiles, release, debug and hardened are built in
+# [profiles.fast]
+# cflags = "-O3 -march=native -pipe"
+# ldflags = "-Wl,-O1"
+# rustflags = "-C target-cpu=native"
+# jobs = 0                         # 0 means settings.jobs
+
+# Per-package options
+# [packages.firefox]
+# profile = "release"
+# skip_check = true                # never run its test suite
 `
 
+	CurrentSettings = DefaultSettings() // effective [settings], applied by LoadConfig
+
+	BuildProfiles = BuiltinProfiles() // built-in and [profiles.<name>] build profiles, set by LoadConfig
+
+	PackageConfigs = map[string]PackageConfig{} // [packages.<name>] tables, set by LoadConfig
+
+	ProfileOverride = "" // --profile, wins over the configured build profiles
+
+	RunChecks = false // --check, run the check phase even when settings.check is off
+
 	DefaultRoot = "/" // Default root directory
 
+	RootDirPath = DefaultRoot // root directory Blink manages, set by ApplyRoot (${root} in recipes)
+
+	ChecksumPolicy = ChecksumPolicyStrict // strict rejects recipes with missing/SKIP checksums, permissive only warns, set from the config
+
+	ChecksumPolicyOverride = "" // --checksum-policy, wins over checksum_policy from the config
+
+	AllowStaleRepos = false // use repositories past their expiry window anyway
+
+	AllowDowngrade = false // let install and update replace a package with an older version
+
+	OutputFormat = OutputText // --output, text or json/yaml for tools (see output.go)
+
 	ConfigFilePath         = filepath.Join(BaseDataDirPath, "etc", "config.toml")
 	LockFilePath           = filepath.Join(BaseDataDirPath, "etc", "blink.lock") // Path to lock file
 	LocalRepositoryDirPath = filepath.Join(BaseDataDirPath, "repositories")
@@ -133,14 +201,23 @@ branch = "main"
 	RecipeDirPath          = filepath.Join(BaseDataDirPath, "recipes")
 	ManifestFilePath       = filepath.Join(BaseDataDirPath, "etc", "manifest.toml")
 	BuildDirPath           = filepath.Join(BaseDataDirPath, "build")
+	KeyringDirPath         = filepath.Join(BaseDataDirPath, "etc", "keys") // Out-of-band trusted public keys
+	StateDirPath           = filepath.Join(BaseDataDirPath, "state")       // Blink's own state, not user configuration
+	RepoStateFilePath      = filepath.Join(StateDirPath, "repos.toml")     // Last verified commit per repository
+	IndexFilePath          = filepath.Join(StateDirPath, "index.json")     // Searchable index of all repositories
+	HoldsFilePath          = filepath.Join(StateDirPath, "holds.toml")     // Held and pinned packages
+	LogDirPath             = filepath.Join(BaseDataDirPath, "log")         // Logs kept after the build dir is gone, like check logs
 
 	lock = &Lock{Path: LockFilePath}
 
+	SupportDiscordURL = "https://discord.com/invite/rx82u93hGD"
+	SupportIssuesURL  = "https://github.com/Aperture-OS/blink-package-manager/issues"
+
 	SupportInformationSnippet = // Support information string
 	`Having trouble? Join our Discord Server or open a GitHub issue.
 	Include any DEBUG INFO logs when reporting issues.
-	Discord: https://discord.com/invite/rx82u93hGD
-	GitHub Issues: https://github.com/Aperture-OS/blink-package-manager/issues`
+	Discord: ` + SupportDiscordURL + `
+	GitHub Issues: ` + SupportIssuesURL
 
 	VersionInformationSnippet = // version information string
 	fmt.Sprintf(`Blink Package Manager - Version %s 
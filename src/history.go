@@ -0,0 +1,243 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Installing older versions: git repositories keep every recipe they ever had
+// in their history, so `blink install foo@1.4.2` looks the recipe up there,
+// verifies the commit it comes from like a sync would, installs it and pins the
+// package so the next update doesn't undo it right away.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// splitVersionSpec splits "foo@1.4.2" into the package and the requested version
+func splitVersionSpec(spec string) (string, string, bool) {
+	name, version, ok := strings.Cut(spec, "@")
+	if !ok {
+		return spec, "", false
+	}
+	return name, version, true
+}
+
+// installVersion installs the newest recipe of a package matching version
+// ("1.4.2" or "1.4.2-3" for a specific release) from its repository's history
+func installVersion(spec string, force bool, path string) error {
+	pkgName, requested, _ := splitVersionSpec(spec)
+
+	want, err := ParseVersion(requested)
+	if err != nil {
+		return fmt.Errorf("invalid version in %q: %v", spec, err)
+	}
+
+	if err := ensureManifest(); err != nil {
+		return err
+	}
+
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return err
+	}
+	if err := ensureRepoOnce(false); err != nil {
+		return fmt.Errorf("failed to update repository: %v", err)
+	}
+
+	repo, _, err := FindRepoForPackage(pkgName, repos)
+	if err != nil {
+		return err
+	}
+	_, name := splitQualifiedName(pkgName)
+
+	backend, err := openRepository(repo)
+	if err != nil {
+		return err
+	}
+	git, ok := backend.(*gitRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is a %s repository, only git repositories keep older recipes", repo.Name, repo.Kind())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	commit, rel, raw, err := findRecipeInHistory(ctx, git.Dir(), name, want)
+	if err != nil {
+		return err
+	}
+	eyes.Infof("Found %s %s in commit %s", name, requested, commit)
+
+	// same trust rules as syncing the repository
+	if len(repo.TrustedKeys) > 0 || repo.AllowedSigners != "" {
+		if err := verifyCommit(ctx, git.Dir(), commit, repo); err != nil {
+			return fmt.Errorf("commit %s of repository %s failed signature verification: %v", commit, repo.Name, err)
+		}
+		eyes.Infof("Commit %s verified", commit)
+	} else if repo.TrustedKey != "" {
+		return fmt.Errorf("repository %s uses the deprecated trusted_key, pin its key in trusted_keys instead", repo.Name)
+	}
+
+	// the old recipe and its signature are checked from the recipe cache
+	cached := recipeCachePath(path, repo.Name, rel)
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(cached, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write recipe: %v", err)
+	}
+	defer os.Remove(cached) // fetchpkg puts the current recipe back when it's needed
+
+	// a signature made for the old recipe lives in the same commit
+	if sig, err := gitShow(ctx, git.Dir(), commit, rel+".sig"); err == nil {
+		if err := os.WriteFile(recipeSignaturePath(cached), sig, 0644); err != nil {
+			return err
+		}
+		defer os.Remove(recipeSignaturePath(cached))
+	}
+	if _, err := verifyRecipeSignature(ctx, repo, cached); err != nil {
+		return err
+	}
+
+	pkg, err := decodeRecipe(raw, recipeFormat(rel))
+	if err != nil {
+		return fmt.Errorf("failed to decode recipe from commit %s: %v", commit, err)
+	}
+	pkg.Repo = repo.Name
+
+	// a build script file is taken from the same commit, the current one may not fit
+	if pkg.Build.ScriptFile != "" {
+		script, err := scriptFromHistory(ctx, git.Dir(), commit, repo, filepath.Dir(cached), pkg.Build.ScriptFile)
+		if err != nil {
+			return err
+		}
+		pkg.Build.Script, pkg.Build.ScriptFile = script, ""
+	}
+
+	// installing another version of an installed package is the point, not a reinstall
+	installed, exists, err := manifestHas(name)
+	if err != nil {
+		return err
+	}
+	reinstall := force || (exists && (installed.Version != pkg.Version || installed.Release != int64(pkg.Release)))
+
+	if err := installPkg(pkg, reinstall, path, ReasonExplicit); err != nil {
+		return err
+	}
+
+	eyes.Infof("Pinning %s to %s so 'blink update' keeps it, 'blink unhold %s' to release it", name, pkg.Version, name)
+	return holdPackage(name, pkg.Version)
+}
+
+// findRecipeInHistory walks the commits touching a recipe, newest first, and
+// returns the first one whose recipe matches want together with that recipe and
+// its path in the repository, the recipe may have been JSON or TOML back then
+func findRecipeInHistory(ctx context.Context, repoPath, name string, want Version) (string, string, []byte, error) {
+	var rels []string
+	for _, ext := range recipeExtensions {
+		rels = append(rels, "recipes/"+name+ext)
+	}
+
+	args := append([]string{"-C", repoPath, "log", "--format=%H", "HEAD", "--"}, rels...)
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to read history of %s: %v", name, err)
+	}
+
+	match := Constraint{Op: "=", Version: want}
+	var seen []string
+
+	for _, commit := range strings.Fields(string(out)) {
+		var rel string
+		var raw []byte
+		for _, candidate := range rels {
+			if raw, err = gitShow(ctx, repoPath, commit, candidate); err == nil {
+				rel = candidate
+				break
+			}
+		}
+		if rel == "" {
+			continue // the recipe was deleted in this commit
+		}
+
+		pkg, err := decodeRecipe(raw, recipeFormat(rel))
+		if err != nil {
+			continue
+		}
+
+		v := pkgVersion(pkg.Version, pkg.Release)
+		if match.Allows(v) {
+			return commit, rel, raw, nil
+		}
+		if len(seen) == 0 || seen[len(seen)-1] != v.String() {
+			seen = append(seen, v.String())
+		}
+	}
+
+	if len(seen) == 0 {
+		return "", "", nil, fmt.Errorf("no recipe found in the repository history")
+	}
+	return "", "", nil, fmt.Errorf("version not found in the repository history (available: %s)", strings.Join(seen, ", "))
+}
+
+// scriptFromHistory reads a build script file as it was in a commit, checking
+// its signature from the same commit in dir
+func scriptFromHistory(ctx context.Context, repoPath, commit string, repo RepoConfig, dir, scriptFile string) (string, error) {
+	local, err := scriptFilePath(dir, scriptFile)
+	if err != nil {
+		return "", err
+	}
+	rel := "recipes/" + filepath.ToSlash(filepath.Clean(filepath.FromSlash(scriptFile)))
+
+	raw, err := gitShow(ctx, repoPath, commit, rel)
+	if err != nil {
+		return "", fmt.Errorf("build script %s is missing from commit %s", rel, commit)
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(local, raw, 0644); err != nil {
+		return "", err
+	}
+	defer os.Remove(local)
+
+	if sig, err := gitShow(ctx, repoPath, commit, rel+".sig"); err == nil {
+		if err := os.WriteFile(recipeSignaturePath(local), sig, 0644); err != nil {
+			return "", err
+		}
+		defer os.Remove(recipeSignaturePath(local))
+	}
+	if _, err := verifyRecipeSignature(ctx, repo, local); err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// gitShow returns a file as it was in a commit
+func gitShow(ctx context.Context, repoPath, commit, rel string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "show", commit+":"+rel)
+	return cmd.Output()
+}
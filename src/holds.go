@@ -0,0 +1,212 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Holds keep packages where they are: a plain hold freezes a package at the
+// installed version, a pin keeps it at one specific version whatever the
+// repository moves to. They are Blink's own state (state/holds.toml), not
+// configuration, and are managed with `blink hold` and `blink unhold`.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+	"github.com/BurntSushi/toml"
+)
+
+// Hold is a single held package
+type Hold struct {
+	Version string    `toml:"version,omitempty"` // pinned version, empty holds whatever is installed
+	Since   time.Time `toml:"since"`             // when the hold was placed
+}
+
+// Holds is the on-disk hold list, keyed by package name
+type Holds struct {
+	Packages map[string]Hold `toml:"holds"`
+}
+
+// HoldError explains why a hold blocks an install or update
+type HoldError struct {
+	Name      string
+	Hold      Hold
+	Installed string // installed version, empty if not installed
+	Wanted    string // version the operation would install
+}
+
+func (e *HoldError) Error() string {
+	if e.Hold.Version != "" {
+		return fmt.Sprintf("%s is pinned to version %s but the repository has %s, run 'blink unhold %s' to allow it",
+			e.Name, e.Hold.Version, e.Wanted, e.Name)
+	}
+	return fmt.Sprintf("%s is held at version %s, run 'blink unhold %s' to allow %s",
+		e.Name, e.Installed, e.Name, e.Wanted)
+}
+
+// loadHolds reads the hold list, a missing file means nothing is held
+func loadHolds() (Holds, error) {
+	holds := Holds{Packages: map[string]Hold{}}
+
+	if _, err := os.Stat(HoldsFilePath); os.IsNotExist(err) {
+		return holds, nil
+	}
+
+	if _, err := toml.DecodeFile(HoldsFilePath, &holds); err != nil {
+		return holds, fmt.Errorf("failed to decode holds: %v", err)
+	}
+	if holds.Packages == nil {
+		holds.Packages = map[string]Hold{}
+	}
+
+	return holds, nil
+}
+
+// saveHolds writes the hold list atomically
+func saveHolds(holds Holds) error {
+	if err := os.MkdirAll(filepath.Dir(HoldsFilePath), 0755); err != nil {
+		return err
+	}
+
+	tmp := HoldsFilePath + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(holds); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, HoldsFilePath)
+}
+
+// holdPackage holds a package, or pins it when version is given. A plain
+// hold only makes sense for an installed package, a pin can come first.
+func holdPackage(pkgName, version string) error {
+	_, name := splitQualifiedName(pkgName)
+
+	return withLock(func() error {
+		installed, exists, err := manifestHas(name)
+		if err != nil {
+			return err
+		}
+		if version == "" && !exists {
+			return fmt.Errorf("package %s is not installed, give a version to pin it before installing", name)
+		}
+
+		holds, err := loadHolds()
+		if err != nil {
+			return err
+		}
+		holds.Packages[name] = Hold{Version: version, Since: time.Now().UTC()}
+		if err := saveHolds(holds); err != nil {
+			return err
+		}
+
+		switch {
+		case version == "":
+			eyes.Successf("Holding %s at version %s", name, installed.Version)
+		case exists && installed.Version != version:
+			eyes.Successf("Pinned %s to version %s (installed: %s, 'blink update' will move it)", name, version, installed.Version)
+		default:
+			eyes.Successf("Pinned %s to version %s", name, version)
+		}
+		return nil
+	})
+}
+
+// unholdPackage removes the hold of a package
+func unholdPackage(pkgName string) error {
+	_, name := splitQualifiedName(pkgName)
+
+	return withLock(func() error {
+		holds, err := loadHolds()
+		if err != nil {
+			return err
+		}
+		if _, ok := holds.Packages[name]; !ok {
+			return fmt.Errorf("package %s is not held", name)
+		}
+
+		delete(holds.Packages, name)
+		if err := saveHolds(holds); err != nil {
+			return err
+		}
+
+		eyes.Successf("Released hold on %s", name)
+		return nil
+	})
+}
+
+// checkHold returns a *HoldError when installing pkg would break a hold,
+// installed is the currently installed package or nil
+func checkHold(pkg PackageInfo, installed *InstalledPkg) error {
+	holds, err := loadHolds()
+	if err != nil {
+		return err
+	}
+
+	hold, ok := holds.Packages[pkg.Name]
+	if !ok {
+		return nil
+	}
+
+	current := ""
+	if installed != nil {
+		current = installed.Version
+	}
+
+	switch {
+	case hold.Version != "" && pkg.Version != hold.Version:
+		return &HoldError{Name: pkg.Name, Hold: hold, Installed: current, Wanted: pkg.Version}
+	case hold.Version == "" && installed != nil && pkg.Version != installed.Version:
+		return &HoldError{Name: pkg.Name, Hold: hold, Installed: current, Wanted: pkg.Version}
+	}
+
+	return nil
+}
+
+// printHolds lists every held package
+func printHolds(holds Holds) {
+	if len(holds.Packages) == 0 {
+		eyes.Infof("No packages are held.")
+		return
+	}
+
+	names := make([]string, 0, len(holds.Packages))
+	for name := range holds.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		hold := holds.Packages[name]
+		kind := "held"
+		if hold.Version != "" {
+			kind = "pinned to " + hold.Version
+		}
+		fmt.Printf("%s: %s (since %s)\n", name, kind, hold.Since.Local().Format(time.RFC1123))
+	}
+}
@@ -0,0 +1,336 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// The package index is a flat list of every recipe in every configured repository,
+// rebuilt after each sync. It's what `blink search` looks through, so searching
+// doesn't require knowing the exact package name anymore.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// IndexEntry is the searchable summary of one recipe
+type IndexEntry struct {
+	Repo        string `json:"repo"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Release     int    `json:"release"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	License     string `json:"license"`
+
+	Signature SignatureOutput `json:"signature"` // recipe signature, checked when the index is built
+}
+
+// PackageIndex is the on-disk index of all repositories
+type PackageIndex struct {
+	Generated time.Time    `json:"generated"`
+	Packages  []IndexEntry `json:"packages"`
+
+	// results of the gpg checks by signatureKey, the next sync reuses them
+	// for recipes that didn't change
+	Signatures map[string]indexedSignature `json:"signatures,omitempty"`
+}
+
+// indexedSignature is the outcome of one recipe signature check
+type indexedSignature struct {
+	Signature SignatureOutput `json:"signature"`
+	Error     string          `json:"error,omitempty"` // why the signature isn't acceptable
+}
+
+// SearchResult is an index entry together with how well it matched
+type SearchResult struct {
+	IndexEntry
+	Score     int  `json:"score"`     // higher is better
+	Installed bool `json:"installed"` // package is in the manifest
+}
+
+// buildIndex reads every recipe of every repository and writes the package index
+func buildIndex(repos map[string]RepoConfig) error {
+	index := PackageIndex{Generated: time.Now().UTC(), Signatures: map[string]indexedSignature{}}
+	previous, _ := readIndex() // only a cache of signature checks here, rebuilt from scratch without it
+
+	for _, name := range sortedRepoNames(repos) {
+		backend, err := openRepository(repos[name])
+		if err != nil {
+			return err
+		}
+
+		files, err := recipeFiles(backend.RecipeDir())
+		if err != nil {
+			return err
+		}
+
+		seen := map[string]bool{}
+		for _, file := range files {
+			if seen[recipeName(file)] {
+				eyes.Warnf("Skipping %s, repository %s already has a recipe for %s", file, name, recipeName(file))
+				continue
+			}
+			seen[recipeName(file)] = true
+
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				eyes.Warnf("Skipping unreadable recipe %s: %v", file, err)
+				continue
+			}
+
+			pkg, err := decodeRecipe(raw, recipeFormat(file))
+			if err != nil {
+				eyes.Warnf("Skipping malformed recipe %s: %v", file, err)
+				continue
+			}
+
+			// checked here so search doesn't run gpg for every result, and
+			// only when something changed since the last sync. Install checks
+			// it again anyway
+			key := signatureKey(repos[name], file, raw)
+			checked, ok := previous.Signatures[key]
+			if key == "" || !ok {
+				sig, err := verifyRecipeSignature(context.Background(), repos[name], file)
+				checked = indexedSignature{Signature: SignatureOutput{Status: sig.Status, Signer: sig.Signer, Fingerprint: sig.Fingerprint}}
+				if err != nil {
+					checked.Error = err.Error()
+				}
+			}
+			if key != "" {
+				index.Signatures[key] = checked
+			}
+			if checked.Error != "" {
+				eyes.Warnf("%s", checked.Error)
+			}
+
+			index.Packages = append(index.Packages, IndexEntry{
+				Repo:        name,
+				Name:        recipeName(file),
+				Version:     pkg.Version,
+				Release:     pkg.Release,
+				Description: pkg.Description,
+				Author:      pkg.Author,
+				License:     pkg.License,
+				Signature:   checked.Signature,
+			})
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(IndexFilePath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := IndexFilePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, IndexFilePath); err != nil {
+		return err
+	}
+
+	eyes.Infof("Indexed %d packages from %d repositories", len(index.Packages), len(repos))
+	return nil
+}
+
+// signatureKey is a digest of everything the signature check of a recipe
+// depends on: the recipe, its signature, the repository's signing settings
+// and the pinned key files. It's "" when there is nothing for gpg to check
+func signatureKey(repo RepoConfig, recipePath string, recipe []byte) string {
+	if len(repo.MaintainerKeys) == 0 {
+		return ""
+	}
+	sig, err := os.ReadFile(recipeSignaturePath(recipePath))
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%s%d:%s%t", len(recipe), recipe, len(sig), sig, repo.RequireSignedRecipes)
+	for _, fpr := range repo.MaintainerKeys {
+		fpr = normalizeFingerprint(fpr)
+		key, err := os.ReadFile(keyringKeyPath(fpr))
+		if err != nil {
+			return "" // let the check report the missing key
+		}
+		fmt.Fprintf(h, "%s%d:%s", fpr, len(key), key)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadIndex reads the package index, syncing repositories first if it doesn't exist yet
+func loadIndex() (PackageIndex, error) {
+	if _, err := os.Stat(IndexFilePath); os.IsNotExist(err) {
+		eyes.Infof("Package index not found, syncing repositories...")
+		if err := ensureRepoOnce(false); err != nil {
+			return PackageIndex{}, fmt.Errorf("failed to sync repositories: %v", err)
+		}
+	}
+	return readIndex()
+}
+
+// readIndex reads the package index as it is on disk
+func readIndex() (PackageIndex, error) {
+	data, err := os.ReadFile(IndexFilePath)
+	if err != nil {
+		return PackageIndex{}, fmt.Errorf("failed to read package index: %v", err)
+	}
+
+	var index PackageIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return PackageIndex{}, fmt.Errorf("failed to decode package index: %v", err)
+	}
+
+	return index, nil
+}
+
+// search weights, a name hit always beats a hit in the other fields
+const (
+	scoreNameExact   = 100
+	scoreNamePrefix  = 60
+	scoreName        = 40
+	scoreDescription = 20
+	scoreAuthor      = 10
+	scoreLicense     = 5
+)
+
+// searchIndex matches term against names, descriptions, authors and licenses.
+// Without useRegex, term is split into keywords which must all match somewhere
+// (case-insensitively). With useRegex, term is a single case-insensitive regexp.
+// Results are ranked by score, then by name and repository.
+func searchIndex(index PackageIndex, term string, useRegex bool) ([]SearchResult, error) {
+	var matchers []*regexp.Regexp
+
+	if useRegex {
+		re, err := regexp.Compile("(?i)" + term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		matchers = append(matchers, re)
+	} else {
+		for _, word := range strings.Fields(term) {
+			matchers = append(matchers, regexp.MustCompile("(?i)"+regexp.QuoteMeta(word)))
+		}
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("empty search term")
+	}
+
+	// by <repo>/<name>, a package of the same name from another repository isn't this one
+	installed := make(map[string]bool)
+	if m, err := loadManifest(); err == nil {
+		for _, p := range m.Installed {
+			installed[qualifiedName(p.Repo, p.Name)] = true
+		}
+	}
+
+	var results []SearchResult
+	for _, entry := range index.Packages {
+		total := 0
+		for _, re := range matchers {
+			score := scoreEntry(entry, re)
+			if score == 0 {
+				total = 0
+				break // every keyword has to match
+			}
+			total += score
+		}
+
+		if total > 0 {
+			// entries installed by an older Blink have no repository and only match by name
+			inManifest := installed[qualifiedName(entry.Repo, entry.Name)] || installed[entry.Name]
+			results = append(results, SearchResult{IndexEntry: entry, Score: total, Installed: inManifest})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Repo < results[j].Repo
+	})
+
+	return results, nil
+}
+
+// scoreEntry returns how well a single matcher fits an entry, 0 means no match
+func scoreEntry(entry IndexEntry, re *regexp.Regexp) int {
+	score := 0
+
+	if loc := re.FindStringIndex(entry.Name); loc != nil {
+		switch {
+		case loc[0] == 0 && loc[1] == len(entry.Name):
+			score += scoreNameExact
+		case loc[0] == 0:
+			score += scoreNamePrefix
+		default:
+			score += scoreName
+		}
+	}
+	if re.MatchString(entry.Description) {
+		score += scoreDescription
+	}
+	if re.MatchString(entry.Author) {
+		score += scoreAuthor
+	}
+	if re.MatchString(entry.License) {
+		score += scoreLicense
+	}
+
+	return score
+}
+
+// printSearchResults shows search results one package per block
+func printSearchResults(results []SearchResult) {
+	for _, r := range results {
+		status := ""
+		if r.Installed {
+			status = " [installed]"
+		}
+
+		signature := r.Signature.Status
+		if signature == "" {
+			signature = "unknown, run 'blink sync' to check it" // index from an older Blink
+		}
+		if r.Signature.Signer != "" {
+			signature += fmt.Sprintf(", signed by %s (%s)", r.Signature.Signer, r.Signature.Fingerprint)
+		}
+
+		fmt.Printf("%s/%s %s-%d%s\n    %s\n    signature: %s\n", r.Repo, r.Name, r.Version, r.Release, status, r.Description, signature)
+	}
+}
@@ -0,0 +1,505 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Blink's keyring lives in /var/blink/etc/keys and holds one armored public key
+// per file, named after the key's fingerprint. Repositories never ship their own
+// trust anchor anymore: the config pins the fingerprints it trusts and the keys
+// themselves are added out-of-band with `blink key add`.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// DefaultKeyserver is used by `blink key refresh` when no keyserver is given
+const DefaultKeyserver = "hkps://keys.openpgp.org"
+
+// KeyInfo describes a public key stored in the keyring
+type KeyInfo struct {
+	Fingerprint string    // primary key fingerprint
+	UserIDs     []string  // user ids attached to the key
+	Subkeys     []string  // fingerprints of the subkeys
+	Created     time.Time // creation time of the primary key
+	Expires     time.Time // zero if the key never expires
+	Revoked     bool      // primary key has been revoked
+}
+
+// Expired reports whether the primary key is past its expiry date
+func (k KeyInfo) Expired() bool {
+	return !k.Expires.IsZero() && time.Now().After(k.Expires)
+}
+
+// Usable reports whether signatures from this key may be trusted
+func (k KeyInfo) Usable() bool {
+	return !k.Revoked && !k.Expired()
+}
+
+// Status returns a short human readable state for listings
+func (k KeyInfo) Status() string {
+	switch {
+	case k.Revoked:
+		return "revoked"
+	case k.Expired():
+		return "expired"
+	default:
+		return "valid"
+	}
+}
+
+// normalizeFingerprint uppercases a fingerprint and strips spaces and 0x prefixes
+// so "0xabcd 1234" and "ABCD1234" compare equal
+func normalizeFingerprint(fpr string) string {
+	fpr = strings.ToUpper(strings.TrimSpace(fpr))
+	fpr = strings.TrimPrefix(fpr, "0X")
+	return strings.ReplaceAll(fpr, " ", "")
+}
+
+// keyringKeyPath returns the keyring file of a primary key fingerprint
+func keyringKeyPath(fpr string) string {
+	return filepath.Join(KeyringDirPath, normalizeFingerprint(fpr)+".asc")
+}
+
+// gpgEnv returns the environment for running gpg against an isolated GNUPGHOME
+func gpgEnv(gnupgHome string) []string {
+	return append(os.Environ(), "GNUPGHOME="+gnupgHome)
+}
+
+// parseGPGColons parses `gpg --with-colons` key listings into KeyInfo entries
+func parseGPGColons(out string) []KeyInfo {
+	var keys []KeyInfo
+	var current *KeyInfo
+	inSub := false
+	subUsable := false // the current subkey isn't revoked, expired or invalid
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "pub":
+			keys = append(keys, KeyInfo{Revoked: fields[1] == "r"})
+			current = &keys[len(keys)-1]
+			inSub = false
+			if len(fields) > 6 {
+				current.Created = colonTime(fields[5])
+				current.Expires = colonTime(fields[6])
+			}
+		case "sub":
+			inSub = true
+			subUsable = fields[1] != "r" && fields[1] != "e" && fields[1] != "i"
+		case "fpr":
+			if current == nil || len(fields) < 10 {
+				continue
+			}
+			if inSub {
+				if subUsable {
+					current.Subkeys = append(current.Subkeys, fields[9])
+				}
+			} else if current.Fingerprint == "" {
+				current.Fingerprint = fields[9]
+			}
+		case "uid":
+			if current != nil && len(fields) > 9 {
+				current.UserIDs = append(current.UserIDs, fields[9])
+			}
+		}
+	}
+
+	return keys
+}
+
+// colonTime converts an epoch field from gpg's colon listing into a time
+func colonTime(field string) time.Time {
+	secs, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// inspectKeyFile lists the keys contained in a public key file without importing them anywhere
+func inspectKeyFile(ctx context.Context, path string) ([]KeyInfo, error) {
+	gnupgHome, err := os.MkdirTemp("", "blink-gnupg-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(gnupgHome)
+
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--with-colons", "--import-options", "show-only", "--import", path)
+	cmd.Env = gpgEnv(gnupgHome)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %v", path, err)
+	}
+
+	return parseGPGColons(string(out)), nil
+}
+
+// listKeys returns every key in Blink's keyring, sorted by fingerprint
+func listKeys(ctx context.Context) ([]KeyInfo, error) {
+	entries, err := os.ReadDir(KeyringDirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []KeyInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".asc") {
+			continue
+		}
+
+		found, err := inspectKeyFile(ctx, filepath.Join(KeyringDirPath, e.Name()))
+		if err != nil {
+			eyes.Warnf("Skipping unreadable key %s: %v", e.Name(), err)
+			continue
+		}
+		keys = append(keys, found...)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Fingerprint < keys[j].Fingerprint })
+	return keys, nil
+}
+
+// findKey resolves a full fingerprint or a unique suffix of one (eg. a long key id)
+// to the key stored in the keyring
+func findKey(ctx context.Context, fpr string) (KeyInfo, error) {
+	fpr = normalizeFingerprint(fpr)
+
+	keys, err := listKeys(ctx)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	var matches []KeyInfo
+	for _, k := range keys {
+		if k.Fingerprint == fpr {
+			return k, nil
+		}
+		if len(fpr) >= 8 && strings.HasSuffix(k.Fingerprint, fpr) {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return KeyInfo{}, fmt.Errorf("key %s not found in keyring", fpr)
+	case 1:
+		return matches[0], nil
+	default:
+		return KeyInfo{}, fmt.Errorf("key id %s is ambiguous, use the full fingerprint", fpr)
+	}
+}
+
+// keyDownloadTimeout is the longest fetching a key over https may take
+const keyDownloadTimeout = 2 * time.Minute
+
+// fetchKeySource returns a local path for a key given as a file path or an https URL,
+// the returned cleanup function removes any temporary download
+func fetchKeySource(source string) (string, func(), error) {
+	if strings.HasPrefix(source, "http://") {
+		// a key is a trust anchor, anyone on the path could swap it
+		return "", nil, fmt.Errorf("refusing to download a key over plain http, use https or a local file")
+	}
+	if !strings.HasPrefix(source, "https://") {
+		return source, func() {}, nil
+	}
+
+	// a key is small, a server that stalls shouldn't hang key add even without download_timeout
+	client := downloadClient()
+	if client.Timeout == 0 || client.Timeout > keyDownloadTimeout {
+		client.Timeout = keyDownloadTimeout
+	}
+	resp, err := client.Get(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download key: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to download key, status: %s", resp.Status)
+	}
+
+	tmp, err := os.CreateTemp("", "blink-key-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, fmt.Errorf("failed to write key: %v", err)
+	}
+
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+}
+
+// exportKeys writes the given primary keys from gnupgHome into the keyring
+// as <FINGERPRINT>.asc, replacing older copies
+func exportKeys(ctx context.Context, gnupgHome string, keys []KeyInfo) error {
+	if err := os.MkdirAll(KeyringDirPath, 0755); err != nil {
+		return fmt.Errorf("failed to create keyring dir: %v", err)
+	}
+
+	for _, k := range keys {
+		cmd := exec.CommandContext(ctx, "gpg", "--batch", "--armor", "--export", k.Fingerprint)
+		cmd.Env = gpgEnv(gnupgHome)
+		armored, err := cmd.Output()
+		if err != nil || len(armored) == 0 {
+			return fmt.Errorf("failed to export key %s: %v", k.Fingerprint, err)
+		}
+
+		// write to a temp file first so a crash never leaves half a key behind
+		dest := keyringKeyPath(k.Fingerprint)
+		if err := os.WriteFile(dest+".tmp", armored, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(dest+".tmp", dest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addKey adds every public key found in source (file or URL) to the keyring
+func addKey(ctx context.Context, source string) ([]KeyInfo, error) {
+	path, cleanup, err := fetchKeySource(source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	keys, err := inspectKeyFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in %s", source)
+	}
+
+	gnupgHome, err := os.MkdirTemp("", "blink-gnupg-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(gnupgHome)
+
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--import", path)
+	cmd.Env = gpgEnv(gnupgHome)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to import key: %v\n%s", err, string(out))
+	}
+
+	if err := exportKeys(ctx, gnupgHome, keys); err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		if !k.Usable() {
+			eyes.Warnf("Key %s is %s, signatures made with it will be rejected", k.Fingerprint, k.Status())
+		}
+	}
+
+	return keys, nil
+}
+
+// removeKey deletes a key from the keyring, repositories still pinning it
+// will fail verification until another key is pinned
+func removeKey(ctx context.Context, fpr string) (KeyInfo, error) {
+	key, err := findKey(ctx, fpr)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	if err := os.Remove(keyringKeyPath(key.Fingerprint)); err != nil {
+		return KeyInfo{}, fmt.Errorf("failed to remove key %s: %v", key.Fingerprint, err)
+	}
+
+	return key, nil
+}
+
+// refreshKeys re-fetches every key in the keyring from a keyserver so new
+// expiry dates, revocations and subkeys are picked up
+func refreshKeys(ctx context.Context, keyserver string) ([]KeyInfo, error) {
+	if keyserver == "" {
+		keyserver = DefaultKeyserver
+	}
+
+	keys, err := listKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var refreshed []KeyInfo
+	for _, k := range keys {
+		gnupgHome, err := os.MkdirTemp("", "blink-gnupg-")
+		if err != nil {
+			return refreshed, err
+		}
+
+		// start from the copy we have so whatever the keyserver sends is merged into it
+		imp := exec.CommandContext(ctx, "gpg", "--batch", "--import", keyringKeyPath(k.Fingerprint))
+		imp.Env = gpgEnv(gnupgHome)
+		if out, err := imp.CombinedOutput(); err != nil {
+			os.RemoveAll(gnupgHome)
+			return refreshed, fmt.Errorf("failed to load key %s: %v\n%s", k.Fingerprint, err, string(out))
+		}
+
+		recv := exec.CommandContext(ctx, "gpg", "--batch", "--keyserver", keyserver, "--recv-keys", k.Fingerprint)
+		recv.Env = gpgEnv(gnupgHome)
+		if out, err := recv.CombinedOutput(); err != nil {
+			eyes.Warnf("Could not refresh %s from %s: %v\n%s", k.Fingerprint, keyserver, err, string(out))
+			os.RemoveAll(gnupgHome)
+			continue
+		}
+
+		err = exportKeys(ctx, gnupgHome, []KeyInfo{k})
+		os.RemoveAll(gnupgHome)
+		if err != nil {
+			return refreshed, err
+		}
+
+		updated, err := inspectKeyFile(ctx, keyringKeyPath(k.Fingerprint))
+		if err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, updated...)
+	}
+
+	return refreshed, nil
+}
+
+// keyringHome creates a scratch GNUPGHOME holding only the pinned keys from Blink's
+// keyring. It returns the home, a map of every usable signing fingerprint (primary
+// keys and subkeys) to its primary fingerprint, and fails if a pinned key is missing.
+// Revoked and expired keys are left out so signatures made with them never verify.
+// The caller must remove the returned directory.
+func keyringHome(ctx context.Context, pinned []string) (string, map[string]string, error) {
+	if len(pinned) == 0 {
+		return "", nil, fmt.Errorf("no key fingerprints pinned")
+	}
+
+	gnupgHome, err := os.MkdirTemp("", "blink-gnupg-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	trusted := make(map[string]string)
+	for _, fpr := range pinned {
+		fpr = normalizeFingerprint(fpr)
+		keyPath := keyringKeyPath(fpr)
+
+		if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+			os.RemoveAll(gnupgHome)
+			return "", nil, fmt.Errorf("pinned key %s is not in the keyring, add it with 'blink key add'", fpr)
+		}
+
+		keys, err := inspectKeyFile(ctx, keyPath)
+		if err != nil {
+			os.RemoveAll(gnupgHome)
+			return "", nil, err
+		}
+
+		for _, k := range keys {
+			if k.Fingerprint != fpr {
+				continue // a key file can't vouch for keys other than the one it's named after
+			}
+			if !k.Usable() {
+				eyes.Warnf("Pinned key %s is %s and will not be trusted", fpr, k.Status())
+				continue
+			}
+			trusted[k.Fingerprint] = k.Fingerprint
+			for _, sub := range k.Subkeys {
+				trusted[sub] = k.Fingerprint
+			}
+		}
+
+		cmd := exec.CommandContext(ctx, "gpg", "--batch", "--import", keyPath)
+		cmd.Env = gpgEnv(gnupgHome)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(gnupgHome)
+			return "", nil, fmt.Errorf("failed to import key %s: %v\n%s", fpr, err, string(out))
+		}
+	}
+
+	if len(trusted) == 0 {
+		os.RemoveAll(gnupgHome)
+		return "", nil, fmt.Errorf("none of the pinned keys are usable (all revoked or expired)")
+	}
+
+	return gnupgHome, trusted, nil
+}
+
+// checkGPGStatus reads gpg's machine readable status output (--status-fd or
+// git's --raw) and makes sure there is a good signature made by one of the
+// trusted fingerprints. It returns the user id and primary fingerprint of the signer.
+func checkGPGStatus(out string, trusted map[string]string) (string, string, error) {
+	var signer, signingFP string
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "[GNUPG:] "))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "GOODSIG":
+			if len(fields) > 2 {
+				signer = strings.Join(fields[2:], " ")
+			}
+		case "VALIDSIG":
+			if len(fields) > 1 {
+				signingFP = fields[1]
+			}
+		case "BADSIG", "ERRSIG":
+			return "", "", fmt.Errorf("bad signature (%s)", fields[0])
+		case "EXPKEYSIG", "EXPSIG":
+			return "", "", fmt.Errorf("signature made by an expired key or expired itself (%s)", fields[0])
+		case "REVKEYSIG":
+			return "", "", fmt.Errorf("signature made by a revoked key")
+		}
+	}
+
+	if signingFP == "" {
+		return "", "", fmt.Errorf("no valid signature found")
+	}
+
+	primary, ok := trusted[signingFP]
+	if !ok {
+		return "", "", fmt.Errorf("signed by %s, which is not a pinned key", signingFP)
+	}
+
+	return signer, primary, nil
+}
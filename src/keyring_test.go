@@ -0,0 +1,120 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeyListing is gpg --with-colons output for two keys: one with a usable
+// and a revoked signing subkey, and a revoked one with an expiry date
+const testKeyListing = `pub:u:255:22:5BA8D1A6B59448E6:1700000000:::u:::scESC:::::ed25519:::0:
+fpr:::::::::33D43958F6B2E4962F2B93E05BA8D1A6B59448E6:
+uid:u::::1700000000::0123456789ABCDEF0123456789ABCDEF01234567::Tester <t@e.com>::::::::::0:
+uid:u::::1700000000::89ABCDEF0123456789ABCDEF0123456789ABCDEF::Tester (work) <t@work.example>::::::::::0:
+sub:u:255:18:1111111111111111:1700000000::::::s:::::cv25519::
+fpr:::::::::AAAAAAAAAAAAAAAAAAAAAAAA1111111111111111:
+sub:r:255:18:2222222222222222:1700000000::::::s:::::cv25519::
+fpr:::::::::BBBBBBBBBBBBBBBBBBBBBBBB2222222222222222:
+pub:r:4096:1:3333333333333333:1600000000:1900000000::-:::sc::::::23::0:
+fpr:::::::::CCCCCCCCCCCCCCCCCCCCCCCC3333333333333333:
+uid:r::::1600000000::FEDCBA9876543210FEDCBA9876543210FEDCBA98::Old <old@e.com>::::::::::0:
+`
+
+func TestParseGPGColons(t *testing.T) {
+	want := []KeyInfo{
+		{
+			Fingerprint: "33D43958F6B2E4962F2B93E05BA8D1A6B59448E6",
+			UserIDs:     []string{"Tester <t@e.com>", "Tester (work) <t@work.example>"},
+			Subkeys:     []string{"AAAAAAAAAAAAAAAAAAAAAAAA1111111111111111"}, // the revoked one is left out
+			Created:     time.Unix(1700000000, 0),
+		},
+		{
+			Fingerprint: "CCCCCCCCCCCCCCCCCCCCCCCC3333333333333333",
+			UserIDs:     []string{"Old <old@e.com>"},
+			Created:     time.Unix(1600000000, 0),
+			Expires:     time.Unix(1900000000, 0),
+			Revoked:     true,
+		},
+	}
+
+	got := parseGPGColons(testKeyListing)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGPGColons() = %+v, want %+v", got, want)
+	}
+	if !got[0].Usable() || got[1].Usable() {
+		t.Errorf("Usable() = %v, %v, want true, false", got[0].Usable(), got[1].Usable())
+	}
+
+	if keys := parseGPGColons("gpg: no valid OpenPGP data found.\n"); len(keys) != 0 {
+		t.Errorf("parseGPGColons of no keys = %+v, want none", keys)
+	}
+}
+
+func TestCheckGPGStatus(t *testing.T) {
+	const (
+		primary = "33D43958F6B2E4962F2B93E05BA8D1A6B59448E6"
+		subkey  = "AAAAAAAAAAAAAAAAAAAAAAAA1111111111111111"
+	)
+	trusted := map[string]string{primary: primary, subkey: primary}
+
+	goodsig := "[GNUPG:] GOODSIG 5BA8D1A6B59448E6 Tester <t@e.com>\n"
+	validsig := func(fpr string) string {
+		return "[GNUPG:] VALIDSIG " + fpr + " 2026-10-18 1792000000 0 4 0 22 10 00 " + primary + "\n"
+	}
+
+	tests := []struct {
+		name    string
+		out     string
+		signer  string
+		wantErr string // part of the error, "" when none is expected
+	}{
+		{name: "good", out: "[GNUPG:] NEWSIG\n" + goodsig + validsig(primary), signer: "Tester <t@e.com>"},
+		{name: "good, by a subkey", out: goodsig + validsig(subkey), signer: "Tester <t@e.com>"},
+		{name: "git --raw", out: "GOODSIG 5BA8D1A6B59448E6 Tester <t@e.com>\n" + strings.TrimPrefix(validsig(primary), "[GNUPG:] "), signer: "Tester <t@e.com>"},
+		{name: "not pinned", out: goodsig + validsig("DDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDD"), wantErr: "not a pinned key"},
+		{name: "bad", out: "[GNUPG:] BADSIG 5BA8D1A6B59448E6 Tester <t@e.com>\n", wantErr: "bad signature (BADSIG)"},
+		{name: "missing key", out: "[GNUPG:] ERRSIG 5BA8D1A6B59448E6 22 10 00 1792000000 9 -\n", wantErr: "bad signature (ERRSIG)"},
+		{name: "expired key", out: "[GNUPG:] EXPKEYSIG 5BA8D1A6B59448E6 Tester <t@e.com>\n" + validsig(primary), wantErr: "expired"},
+		{name: "revoked key", out: "[GNUPG:] REVKEYSIG 5BA8D1A6B59448E6 Tester <t@e.com>\n" + validsig(primary), wantErr: "revoked"},
+		{name: "no VALIDSIG", out: goodsig, wantErr: "no valid signature"},
+		{name: "empty", out: "", wantErr: "no valid signature"},
+	}
+	for _, tt := range tests {
+		signer, fpr, err := checkGPGStatus(tt.out, trusted)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if signer != tt.signer || fpr != primary {
+			t.Errorf("%s: = %q, %q, want %q, %q", tt.name, signer, fpr, tt.signer, primary)
+		}
+	}
+}
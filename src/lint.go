@@ -0,0 +1,698 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Recipe linting: `blink lint` checks recipes before anyone tries to install
+// them, the schema (field names and types) as well as what install would only
+// find out halfway through (build kinds, checksums, archive formats, optional
+// dependency defaults, dependencies nobody provides). Every problem points at
+// the JSON path it's about, and a whole repository can be linted in CI.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// lint severities
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintProblem is one thing wrong with a recipe
+type LintProblem struct {
+	File     string `json:"file"`
+	Path     string `json:"path"` // JSON path inside the recipe, "$" for the whole document
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// LintReport is the result of linting a set of recipes
+type LintReport struct {
+	Recipes  int           `json:"recipes"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Problems []LintProblem `json:"problems"`
+}
+
+// lintCandidate is a package a dependency could resolve to
+type lintCandidate struct {
+	Repo    string
+	Version Version
+}
+
+// linter collects problems and knows which packages exist
+type linter struct {
+	report   LintReport
+	packages map[string][]lintCandidate // by package name
+	repos    map[string]bool            // repository names dependencies may be qualified with
+	complete bool                       // packages covers the configured repositories, unknown dependencies are errors
+}
+
+// identifiers can be written as .key in a JSON path, anything else as ["key"]
+var jsonPathIdentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPathKey appends an object key to a JSON path
+func jsonPathKey(path, key string) string {
+	if jsonPathIdentRe.MatchString(key) {
+		return path + "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return path + "[" + string(quoted) + "]"
+}
+
+// add records a problem
+func (l *linter) add(file, path, severity, format string, args ...any) {
+	l.report.Problems = append(l.report.Problems, LintProblem{
+		File:     file,
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+	if severity == LintError {
+		l.report.Errors++
+	} else {
+		l.report.Warnings++
+	}
+}
+
+// lintTargetFiles resolves what `blink lint` was pointed at: a recipe file, a
+// directory of recipes, a repository checkout (a directory with recipes/) or
+// the name of a configured repository. repo is the repository name the recipes
+// belong to, if known
+func lintTargetFiles(target string, repos map[string]RepoConfig) (files []string, repo string, err error) {
+	info, statErr := os.Stat(target)
+
+	switch {
+	case statErr == nil && !info.IsDir():
+		return []string{target}, "", nil
+
+	case statErr == nil:
+		dir := target
+		if sub, err := os.Stat(filepath.Join(target, "recipes")); err == nil && sub.IsDir() {
+			dir = filepath.Join(target, "recipes")
+		}
+		files, err = recipeFiles(dir)
+		return files, "", err
+
+	default:
+		configured, ok := repos[target]
+		if !ok {
+			return nil, "", fmt.Errorf("%s is neither a recipe, a directory nor a configured repository", target)
+		}
+		backend, err := openRepository(configured)
+		if err != nil {
+			return nil, "", err
+		}
+		files, err = recipeFiles(backend.RecipeDir())
+		return files, target, err
+	}
+}
+
+// lintRecipes lints files. Dependencies are resolved against the recipes being
+// linted and, when index is not nil, the configured repositories
+func lintRecipes(files []string, repo string, repos map[string]RepoConfig, index *PackageIndex) LintReport {
+	l := &linter{
+		report:   LintReport{Problems: []LintProblem{}},
+		packages: map[string][]lintCandidate{},
+		repos:    map[string]bool{},
+		complete: index != nil,
+	}
+	for name := range repos {
+		l.repos[name] = true
+	}
+	if repo != "" {
+		l.repos[repo] = true
+	}
+	if index != nil {
+		for _, e := range index.Packages {
+			if repo != "" && e.Repo == repo {
+				continue // the recipes being linted replace what was synced
+			}
+			l.known(e.Name, e.Repo, e.Version, e.Release)
+		}
+	}
+
+	// first pass: schema, and learn what the linted recipes provide
+	recipes := make(map[string]PackageInfo, len(files))
+	seen := map[string]string{}
+	for _, file := range files {
+		l.report.Recipes++
+
+		key := filepath.Join(filepath.Dir(file), recipeName(file))
+		if other, ok := seen[key]; ok {
+			l.add(file, "$", LintError, "%s is the same package, Blink only reads %s", filepath.Base(other), filepath.Base(other))
+			continue
+		}
+		seen[key] = file
+
+		if pkg, ok := l.lintSchema(file); ok {
+			recipes[file] = pkg
+			l.known(recipeName(file), repo, pkg.Version, pkg.Release)
+		}
+	}
+
+	// second pass: semantics, which need every recipe known
+	for _, file := range files {
+		if pkg, ok := recipes[file]; ok {
+			l.lintSemantics(file, pkg)
+		}
+	}
+
+	sort.SliceStable(l.report.Problems, func(i, j int) bool {
+		return l.report.Problems[i].File < l.report.Problems[j].File
+	})
+	return l.report
+}
+
+// known records an available package
+func (l *linter) known(name, repo, version string, release int) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return
+	}
+	if release > 0 {
+		v.Release = release
+	}
+	l.packages[name] = append(l.packages[name], lintCandidate{Repo: repo, Version: v})
+}
+
+// lintSchema decodes a recipe and checks every field against PackageInfo
+func (l *linter) lintSchema(file string) (PackageInfo, bool) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		l.add(file, "$", LintError, "unreadable: %v", err)
+		return PackageInfo{}, false
+	}
+
+	// older schema versions are still read, checked as what they migrate to
+	raw, doc, from, err := loadRecipeDocument(raw, recipeFormat(file))
+	if err != nil {
+		l.add(file, "$", LintError, "%v", err)
+		return PackageInfo{}, false
+	}
+	if from < RecipeSchemaVersion {
+		l.add(file, "$.schema_version", LintWarning, "schema version %d is older than %d, run 'blink recipe migrate %s'", from, RecipeSchemaVersion, file)
+	}
+
+	before := l.report.Errors
+	l.checkType(file, "$", doc, reflect.TypeOf(PackageInfo{}))
+
+	// mistyped fields are left empty, the rest is still worth checking
+	var pkg PackageInfo
+	if err := json.Unmarshal(raw, &pkg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) || l.report.Errors == before {
+			l.add(file, "$", LintError, "%v", err)
+		}
+		if !errors.As(err, &typeErr) {
+			return PackageInfo{}, false
+		}
+	}
+	return pkg, true
+}
+
+// checkType checks a decoded JSON value against the Go type the recipe decodes into
+func (l *linter) checkType(file, path string, v any, t reflect.Type) {
+	if v == nil {
+		return // null decodes to the zero value
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			l.add(file, path, LintError, "expected an object, got %s", jsonKind(v))
+			return
+		}
+
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		for _, key := range sortedKeys(obj) {
+			ft, ok := fields[key]
+			if !ok {
+				l.add(file, jsonPathKey(path, key), LintError, "unknown field %q%s", key, didYouMean(key, fields))
+				continue
+			}
+			l.checkType(file, jsonPathKey(path, key), obj[key], ft)
+		}
+
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			l.add(file, path, LintError, "expected an object, got %s", jsonKind(v))
+			return
+		}
+		for _, key := range sortedKeys(obj) {
+			l.checkType(file, jsonPathKey(path, key), obj[key], t.Elem())
+		}
+
+	case reflect.Slice:
+		arr, ok := v.([]any)
+		if !ok {
+			l.add(file, path, LintError, "expected an array, got %s", jsonKind(v))
+			return
+		}
+		for i, value := range arr {
+			l.checkType(file, fmt.Sprintf("%s[%d]", path, i), value, t.Elem())
+		}
+
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			l.add(file, path, LintError, "expected a string, got %s", jsonKind(v))
+		}
+
+	case reflect.Int, reflect.Int64:
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			l.add(file, path, LintError, "expected an integer, got %s", jsonKind(v))
+		}
+
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			l.add(file, path, LintError, "expected true or false, got %s", jsonKind(v))
+		}
+	}
+}
+
+// jsonKind names the type of a decoded JSON value for messages
+func jsonKind(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case float64:
+		return fmt.Sprintf("the number %v", v)
+	case bool:
+		return fmt.Sprintf("%v", v)
+	default:
+		return "null"
+	}
+}
+
+// didYouMean suggests the known field closest to a misspelled one
+func didYouMean(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3 // more than two edits away isn't a typo anymore
+	for name := range fields {
+		if d := editDistance(strings.ToLower(key), name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// offsetPosition turns a byte offset into a 1-based line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	line, col := 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// checksum lengths in hex, per algorithm
+var checksumHexLen = map[string]int{"sha256": 64, "sha512": 128, "blake2b": 128}
+
+// lintSemantics checks what the schema can't: values that install would reject
+func (l *linter) lintSemantics(file string, pkg PackageInfo) {
+	stem := recipeName(file)
+
+	// identity
+	switch {
+	case pkg.Name == "":
+		l.add(file, "$.name", LintError, "name is empty")
+	case pkg.Name != stem:
+		l.add(file, "$.name", LintError, "name %q doesn't match the file name %s, packages are found by file name", pkg.Name, filepath.Base(file))
+	}
+	if pkg.Version == "" {
+		l.add(file, "$.version", LintError, "version is empty")
+	} else if _, err := ParseVersion(pkg.Version); err != nil {
+		l.add(file, "$.version", LintError, "%v", err)
+	}
+	if pkg.Release < 0 {
+		l.add(file, "$.release", LintError, "release can't be negative")
+	}
+	if pkg.Description == "" {
+		l.add(file, "$.description", LintWarning, "description is empty, 'blink search' has nothing to match")
+	}
+	if pkg.License == "" {
+		l.add(file, "$.license", LintWarning, "license is empty")
+	}
+
+	// the generated commands are linted like written ones
+	if err := applyBuildSystem(&pkg); err != nil {
+		path := "$.build.system"
+		if pkg.Build.System == "" {
+			path = "$.build.args"
+		}
+		l.add(file, path, LintError, "%v", err)
+	}
+
+	l.lintSource(file, l.lintVars(file, pkg))
+	l.lintBuild(file, pkg)
+
+	// dependencies, runtime, build and check ones
+	for _, list := range []struct {
+		key  string
+		deps map[string]string
+	}{{"dependencies", pkg.Dependencies}, {"build_dependencies", pkg.BuildDependencies}, {"check_dependencies", pkg.CheckDependencies}} {
+		for _, dep := range sortedKeys(list.deps) {
+			path := jsonPathKey("$."+list.key, dep)
+			if _, name := splitQualifiedName(dep); name == pkg.Name {
+				l.add(file, path, LintError, "%s depends on itself", pkg.Name)
+				continue
+			}
+			if _, ok := pkg.Dependencies[dep]; ok && list.key != "dependencies" {
+				l.add(file, path, LintWarning, "%s is a runtime dependency already, it's installed before building anyway", dep)
+			}
+			constraints, err := ParseConstraints(list.deps[dep])
+			if err != nil {
+				l.add(file, path, LintError, "%v", err)
+				continue
+			}
+			l.lintReference(file, path, dep, constraints)
+		}
+	}
+	if len(pkg.BuildDependencies)+len(pkg.CheckDependencies) > 0 && strings.EqualFold(strings.TrimSpace(pkg.Build.Kind), "preCompiled") {
+		l.add(file, "$.build_dependencies", LintWarning, "preCompiled packages aren't built, their build and check dependencies are never installed")
+	}
+
+	// optional dependency groups
+	ids := map[int]bool{}
+	for i, group := range pkg.OptDeps {
+		path := fmt.Sprintf("$.opt_dependencies[%d]", i)
+		if ids[group.ID] {
+			l.add(file, path+".id", LintError, "duplicate group id %d", group.ID)
+		}
+		ids[group.ID] = true
+
+		if len(group.Options) == 0 {
+			l.add(file, path+".options", LintError, "group has no options")
+		}
+		for j, opt := range group.Options {
+			l.lintReference(file, fmt.Sprintf("%s.options[%d]", path, j), opt, nil)
+		}
+		if group.Default != "" && !slices.Contains(group.Options, group.Default) {
+			l.add(file, path+".default", LintError, "default %q is not one of the options %v", group.Default, group.Options)
+		}
+	}
+}
+
+// lintVars checks that every ${variable} is defined and returns pkg with its
+// source url expanded, for the checks after it
+func (l *linter) lintVars(file string, pkg PackageInfo) PackageInfo {
+	vars, err := newRecipeVars(pkg, filepath.Join(BuildDirPath, pkg.Name))
+	if err != nil {
+		l.add(file, "$.vars", LintError, "%v", err)
+		return pkg
+	}
+
+	if expanded, err := vars.Expand(pkg.Source.URL); err != nil {
+		l.add(file, "$.source.url", LintError, "%v", err)
+	} else {
+		pkg.Source.URL = expanded
+	}
+
+	vars.SetSrcDir(filepath.Join(BuildDirPath, pkg.Name, "src"))
+	for _, name := range sortedKeys(pkg.Vars) {
+		if _, err := vars.Expand(pkg.Vars[name]); err != nil {
+			l.add(file, jsonPathKey("$.vars", name), LintError, "%v", err)
+		}
+	}
+	for _, key := range sortedKeys(pkg.Build.Env) {
+		if _, err := vars.Expand(pkg.Build.Env[key]); err != nil {
+			l.add(file, jsonPathKey("$.build.env", key), LintError, "%v", err)
+		}
+	}
+	for _, list := range commandLists(&pkg) {
+		for i, cmd := range *list.Cmds {
+			if _, err := vars.Expand(cmd); err != nil {
+				l.add(file, fmt.Sprintf("$.build.%s[%d]", list.Name, i), LintError, "%v", err)
+			}
+		}
+	}
+	for i, arg := range pkg.Build.Args {
+		if _, err := vars.Expand(arg); err != nil {
+			l.add(file, fmt.Sprintf("$.build.args[%d]", i), LintError, "%v", err)
+		}
+	}
+	return pkg
+}
+
+// lintSource checks the source url, archive format and checksums
+func (l *linter) lintSource(file string, pkg PackageInfo) {
+	src := pkg.Source
+
+	if src.URL == "" {
+		l.add(file, "$.source.url", LintError, "source url is empty")
+	} else if u, err := url.Parse(src.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		l.add(file, "$.source.url", LintError, "source url %q is not an http(s) url", src.URL)
+	} else if suffix := archiveSuffix(u.Path); suffix == "" {
+		l.add(file, "$.source.url", LintError, "unsupported archive format %q (supported: %s)", filepath.Base(u.Path), strings.Join(archiveSuffixes, ", "))
+	} else if src.Type != "" && "."+strings.TrimPrefix(strings.ToLower(src.Type), ".") != suffix {
+		l.add(file, "$.source.type", LintWarning, "type %q doesn't match the url (%s), the url decides how it's extracted", src.Type, suffix)
+	}
+
+	declared := declaredChecksums(pkg)
+	if len(declared) == 0 {
+		l.add(file, "$.source.sha256", LintError, "no checksum declared, the strict checksum policy refuses this recipe")
+	}
+	for _, algo := range SupportedChecksums {
+		sum, ok := declared[algo]
+		if !ok {
+			continue
+		}
+		path := "$.source." + algo
+		if strings.EqualFold(sum, ChecksumSkip) {
+			l.add(file, path, LintWarning, "%s verification is skipped, the strict checksum policy refuses this recipe", algo)
+			continue
+		}
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != checksumHexLen[algo] {
+			l.add(file, path, LintError, "not a %s digest (expected %d hex characters)", algo, checksumHexLen[algo])
+		}
+	}
+}
+
+// installsToDestdir tells whether some install command uses ${destdir}, what
+// installs there is recorded and removed on uninstall
+func installsToDestdir(pkg PackageInfo) bool {
+	for _, cmd := range pkg.Build.Install {
+		if strings.Contains(cmd, "${destdir}") {
+			return true
+		}
+	}
+	return false
+}
+
+// lintBuild checks the build kind and commands
+func (l *linter) lintBuild(file string, pkg PackageInfo) {
+	switch strings.ToLower(strings.TrimSpace(pkg.Build.Kind)) {
+	case "tocompile":
+		if len(pkg.Build.Install) == 0 && !usesBuildScript(pkg) {
+			l.add(file, "$.build.install", LintError, "toCompile recipes need install commands")
+		}
+	case "precompiled":
+	case "":
+		l.add(file, "$.build.kind", LintError, "build kind is empty (expected toCompile or preCompiled)")
+	default:
+		l.add(file, "$.build.kind", LintError, "unknown build kind %q (expected toCompile or preCompiled)", pkg.Build.Kind)
+	}
+
+	if usesBuildScript(pkg) {
+		l.lintScript(file, pkg)
+	} else if strings.EqualFold(strings.TrimSpace(pkg.Build.Kind), "toCompile") && len(pkg.Build.Uninstall) == 0 && !installsToDestdir(pkg) {
+		l.add(file, "$.build.install", LintWarning, "install commands don't install into ${destdir} and there are no uninstall commands, 'blink uninstall' will leave the files behind")
+	}
+	for _, key := range sortedKeys(pkg.Build.Env) {
+		if key == "" || strings.ContainsAny(key, "= ") {
+			l.add(file, jsonPathKey("$.build.env", key), LintError, "invalid environment variable name %q", key)
+		}
+	}
+	for _, list := range commandLists(&pkg) {
+		for i, cmd := range *list.Cmds {
+			if strings.TrimSpace(cmd) == "" {
+				l.add(file, fmt.Sprintf("$.build.%s[%d]", list.Name, i), LintWarning, "empty command")
+			}
+		}
+	}
+}
+
+// scriptFuncPattern finds the phase functions a build script defines, lint doesn't run scripts
+var scriptFuncPattern = regexp.MustCompile(`(?m)^\s*(?:function\s+)?([a-z]+)\s*\(\s*\)`)
+
+// lintScript checks a build script: that it's readable, parses, and defines the functions that matter
+func (l *linter) lintScript(file string, pkg PackageInfo) {
+	path := "$.build.script"
+	if pkg.Build.ScriptFile != "" {
+		path = "$.build.script_file"
+	}
+	if err := checkBuildScript(pkg); err != nil {
+		l.add(file, path, LintError, "%v", err)
+		return
+	}
+
+	script := pkg.Build.Script
+	if pkg.Build.ScriptFile != "" {
+		scriptPath, err := scriptFilePath(filepath.Dir(file), pkg.Build.ScriptFile)
+		if err != nil {
+			l.add(file, path, LintError, "%v", err)
+			return
+		}
+		data, err := os.ReadFile(scriptPath)
+		if err != nil {
+			l.add(file, path, LintError, "build script %s: %v", pkg.Build.ScriptFile, err)
+			return
+		}
+		script = string(data)
+	}
+
+	// sh -n only parses
+	cmd := exec.Command("sh", "-n")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		l.add(file, path, LintError, "build script doesn't parse: %s", strings.TrimSpace(string(out)))
+		return
+	}
+
+	defined := map[string]bool{}
+	for _, m := range scriptFuncPattern.FindAllStringSubmatch(script, -1) {
+		defined[m[1]] = true
+	}
+	if !defined["package"] {
+		l.add(file, path, LintWarning, "build script has no package() function, nothing installs the package")
+	}
+	if !defined["uninstall"] && !strings.Contains(script, "destdir") {
+		l.add(file, path, LintWarning, "package() doesn't install into $destdir and there is no uninstall(), 'blink uninstall' will leave the files behind")
+	}
+}
+
+// lintReference checks that a dependency exists and some version of it satisfies constraints
+func (l *linter) lintReference(file, path, dep string, constraints []Constraint) {
+	repo, name := splitQualifiedName(dep)
+
+	if repo != "" && !l.repos[repo] {
+		l.add(file, path, l.unknownSeverity(), "repository %q is not configured", repo)
+		return
+	}
+
+	var candidates []lintCandidate
+	for _, c := range l.packages[name] {
+		if repo == "" || c.Repo == repo {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		l.add(file, path, l.unknownSeverity(), "package %s not found in the linted recipes or any configured repository", dep)
+		return
+	}
+
+	for _, c := range candidates {
+		if constraintsAllow(constraints, c.Version) {
+			return
+		}
+	}
+	l.add(file, path, LintError, "no available version of %s satisfies %q", dep, formatConstraints(constraints))
+}
+
+// unknownSeverity is how bad a dependency nobody provides is, an error when the
+// configured repositories are known and a warning when they couldn't be checked
+func (l *linter) unknownSeverity() string {
+	if l.complete {
+		return LintError
+	}
+	return LintWarning
+}
+
+// formatConstraints prints constraints the way recipes write them
+func formatConstraints(constraints []Constraint) string {
+	parts := make([]string, 0, len(constraints))
+	for _, c := range constraints {
+		parts = append(parts, c.Op+c.Version.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys returns the keys of a map in order, so reports don't change between runs
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printLintReport shows problems one per line, like a compiler would
+func printLintReport(report LintReport) {
+	for _, p := range report.Problems {
+		fmt.Printf("%s: %s: %s: %s\n", p.File, p.Path, p.Severity, p.Message)
+	}
+	fmt.Printf("%d recipes, %d errors, %d warnings\n", report.Recipes, report.Errors, report.Warnings)
+}
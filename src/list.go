@@ -0,0 +1,240 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Listing installed packages, and comparing them against what the
+// repositories offer to find the ones with updates.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+)
+
+// OutdatedExitCode is what `blink outdated` exits with when updates are available,
+// 0 means everything is up to date and 1 is kept for errors
+const OutdatedExitCode = 100
+
+// ListFilter selects installed packages for `blink list`, empty fields match everything
+type ListFilter struct {
+	Explicit bool   // only packages the user asked for
+	Deps     bool   // only packages pulled in as dependencies, build dependencies included
+	Orphans  bool   // only dependencies nothing installed needs anymore
+	Repo     string // only packages installed from this repository
+	Pattern  string // glob the package name has to match
+}
+
+// OutdatedPkg is an installed package whose repository has another version
+type OutdatedPkg struct {
+	Installed InstalledPkg `json:"installed"`
+	Available IndexEntry   `json:"available"`
+	Downgrade bool         `json:"downgrade"` // the repository version is older than the installed one
+	Held      bool         `json:"held"`      // held or pinned to another version, 'blink update' leaves it alone
+}
+
+// filterInstalled returns the installed packages matching f, sorted by name
+func filterInstalled(m Manifest, f ListFilter) ([]InstalledPkg, error) {
+	if f.Explicit && (f.Deps || f.Orphans) {
+		return nil, fmt.Errorf("--explicit can't be combined with --deps or --orphans")
+	}
+	if f.Pattern != "" {
+		if _, err := path.Match(f.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", f.Pattern, err)
+		}
+	}
+
+	orphans := findOrphans(m)
+
+	var out []InstalledPkg
+	for _, p := range m.Installed {
+		switch {
+		case f.Explicit && p.Reason == ReasonDependency:
+			continue
+		case f.Deps && p.Reason != ReasonDependency && p.Reason != ReasonBuild:
+			continue
+		case f.Orphans && !orphans[p.Name]:
+			continue
+		case f.Repo != "" && p.Repo != f.Repo:
+			continue
+		}
+		if f.Pattern != "" {
+			if ok, _ := path.Match(f.Pattern, p.Name); !ok {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// findOrphans returns the packages installed as dependencies, build dependencies
+// included, that no installed package depends on
+func findOrphans(m Manifest) map[string]bool {
+	needed := map[string]bool{}
+	for _, p := range m.Installed {
+		for _, dep := range p.Depends {
+			needed[dep] = true
+		}
+	}
+
+	orphans := map[string]bool{}
+	for _, p := range m.Installed {
+		if (p.Reason == ReasonDependency || p.Reason == ReasonBuild) && !needed[p.Name] {
+			orphans[p.Name] = true
+		}
+	}
+	return orphans
+}
+
+// findOutdated compares installed packages against the package index. Packages
+// stay on the repository they were installed from, packages recorded without
+// one are compared against the highest priority repository providing them.
+func findOutdated(installed []InstalledPkg, index PackageIndex, repos map[string]RepoConfig, holds Holds) []OutdatedPkg {
+	entries := map[string]IndexEntry{}
+	for _, e := range index.Packages {
+		entries[qualifiedName(e.Repo, e.Name)] = e
+	}
+
+	var outdated []OutdatedPkg
+	for _, inst := range installed {
+		entry, ok := entries[qualifiedName(inst.Repo, inst.Name)]
+		if !ok && inst.Repo == "" {
+			for _, repo := range reposByPriority(enabledRepos(repos)) {
+				if entry, ok = entries[qualifiedName(repo.Name, inst.Name)]; ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		cmp := compareVersions(pkgVersion(entry.Version, entry.Release), pkgVersion(inst.Version, int(inst.Release)))
+		if cmp != 0 {
+			hold, held := holds.Packages[inst.Name]
+			outdated = append(outdated, OutdatedPkg{
+				Installed: inst,
+				Available: entry,
+				Downgrade: cmp < 0,
+				Held:      held && (hold.Version == "" || hold.Version != entry.Version),
+			})
+		}
+	}
+
+	sort.Slice(outdated, func(i, j int) bool {
+		return outdated[i].Installed.Name < outdated[j].Installed.Name
+	})
+	return outdated
+}
+
+// checkOutdated syncs the repositories (unless sync is false, then the
+// current index is used) and compares installed against them
+func checkOutdated(installed []InstalledPkg, repos map[string]RepoConfig, sync bool) ([]OutdatedPkg, error) {
+	if sync {
+		if err := ensureRepoOnce(false); err != nil {
+			return nil, fmt.Errorf("failed to sync repositories: %v", err)
+		}
+	}
+	index, err := loadIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package index: %v", err)
+	}
+	holds, err := loadHolds()
+	if err != nil {
+		return nil, err
+	}
+	return findOutdated(installed, index, repos, holds), nil
+}
+
+// countUpdates counts the outdated packages 'blink update' would actually update
+func countUpdates(outdated []OutdatedPkg) int {
+	n := 0
+	for _, o := range outdated {
+		if !o.Held && !o.Downgrade {
+			n++
+		}
+	}
+	return n
+}
+
+// printInstalled lists installed packages as a table
+func printInstalled(installed []InstalledPkg) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tREPO\tREASON\tPROFILE\tINSTALLED\tSIZE")
+
+	for _, p := range installed {
+		repo := p.Repo
+		if repo == "" {
+			repo = "unknown"
+		}
+		reason := p.Reason
+		if reason == "" {
+			reason = "-"
+		}
+		profile := p.Profile
+		if profile == "" {
+			profile = "-"
+		}
+		date := "-"
+		if !p.InstalledAt.IsZero() {
+			date = p.InstalledAt.Local().Format("2006-01-02 15:04")
+		}
+		size := "-"
+		if p.Size > 0 {
+			size = formatSize(p.Size)
+		}
+
+		fmt.Fprintf(w, "%s\t%s-%d\t%s\t%s\t%s\t%s\t%s\n", p.Name, p.Version, p.Release, repo, reason, profile, date, size)
+	}
+	w.Flush()
+}
+
+// printOutdated lists packages with another version available
+func printOutdated(outdated []OutdatedPkg) {
+	for _, o := range outdated {
+		note := ""
+		switch {
+		case o.Held:
+			note = " (held)"
+		case o.Downgrade:
+			note = " (downgrade)"
+		}
+		fmt.Printf("%s %s-%d -> %s-%d [%s]%s\n", o.Installed.Name, o.Installed.Version, o.Installed.Release,
+			o.Available.Version, o.Available.Release, o.Available.Repo, note)
+	}
+}
+
+// formatSize prints a byte count the way humans read it
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
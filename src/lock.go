@@ -125,3 +125,18 @@ func (l *Lock) IsLocked() (bool, error) {
 
 	return false, nil
 }
+
+// withLock runs fn while holding Blink's lock, so config and state files
+// aren't rewritten under a running install or sync
+func withLock(fn func() error) error {
+	if err := lock.Acquire(); err != nil {
+		return fmt.Errorf("could not acquire lock: %v", err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			eyes.Errorf("Failed to release lock: %v", err)
+		}
+	}()
+
+	return fn()
+}
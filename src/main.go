@@ -22,8 +22,10 @@ package main // main package, entry point
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/fang" // For fancy terminal output
@@ -109,16 +111,25 @@ func main() {
 		FatalTextColor:   color.New(color.BgRed, color.Bold, color.FgWhite),
 	})
 
+	// default_root from the host config becomes the default of --root
+	if configured := hostDefaultRoot(); configured != "" {
+		DefaultRoot = configured
+	}
+
 	// Flags for CLI commands
 	var force bool  // Force re-download or reinstall
 	var path string // Custom cache path
 	var root = DefaultRoot
+	var removeBuild bool // --remove-build-deps, or remove_build_deps from the config
 
 	//  Root command
 	rootCmd := &cobra.Command{
 		Use:   "blink",
 		Short: fmt.Sprintf("Blink - lightweight, source-based package manager for %s", DistroName),
 		Long:  fmt.Sprintf("Blink - lightweight, fast, source-based package manager for %s and Linux systems.", DistroName),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setupOutput(OutputFormat)
+		},
 	}
 
 	//  blink get <pkg>
@@ -132,15 +143,15 @@ func main() {
 			requireRoot() // ensure running as root
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
-			_, err := LoadConfig()
+			repos, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
 			}
 
 			if path == "" {
@@ -149,44 +160,88 @@ func main() {
 
 			for _, pkgName := range args {
 				if err := getpkg(pkgName, path); err != nil {
-					eyes.Errorf("Failed to fetch %s: %v", pkgName, err)
-					return
+					fatalf("Failed to fetch %s: %v", pkgName, err)
+				}
+
+				if machineOutput() {
+					repo, recipePath, err := FindRepoForPackage(pkgName, repos)
+					if err != nil {
+						fatalf("%v", err)
+					}
+					_, name := splitQualifiedName(pkgName)
+					emitResult(RecipeOutput{Repo: repo.Name, Name: name, Path: recipeCachePath(path, repo.Name, recipePath)})
 				}
 			}
 
 		},
 	}
 
-	//  blink search <pkg>
+	//  blink search <term>
+	var exact, useRegex bool
 	infoCmd := &cobra.Command{
-		Use:     "search <pkg>",
-		Short:   "Fetch & display package information",
-		Args:    cobra.ExactArgs(1),
+		Use:     "search <term>",
+		Short:   "Search packages, or display a package's information with --exact",
+		Args:    cobra.MinimumNArgs(1),
 		Aliases: []string{"information", "pkginfo", "details", "fetch", "info", "f", "searchfor"},
 		Run: func(cmd *cobra.Command, args []string) {
 
 			requireRoot() // ensure running as root
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
 			_, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
 			}
 
 			if path == "" {
 				path = RecipeDirPath
 			}
 
+			if !exact {
+				index, err := loadIndex()
+				if err != nil {
+					fatalf("Failed to load package index: %v", err)
+				}
+
+				results, err := searchIndex(index, strings.Join(args, " "), useRegex)
+				if err != nil {
+					fatalf("Search failed: %v", err)
+				}
+				if machineOutput() {
+					emitResult(append([]SearchResult{}, results...))
+					return
+				}
+				if len(results) == 0 {
+					eyes.Warnf("No packages match %q", strings.Join(args, " "))
+					return
+				}
+
+				printSearchResults(results)
+				return
+			}
+
+			if machineOutput() {
+				details := []PackageOutput{}
+				for _, pkgName := range args {
+					out, err := packageOutput(path, force, pkgName)
+					if err != nil {
+						fatalf("Failed to fetch info for %s: %v", pkgName, err)
+					}
+					details = append(details, out)
+				}
+				emitResult(details)
+				return
+			}
+
 			for _, pkgName := range args {
 				if _, err := fetchpkg(path, force, pkgName, false); err != nil {
-					eyes.Errorf("Failed to fetch info for %s: %v", pkgName, err)
-					return
+					fatalf("Failed to fetch info for %s: %v", pkgName, err)
 				}
 			}
 
@@ -195,7 +250,7 @@ func main() {
 
 	//  blink install <pkg>
 	installCmd := &cobra.Command{
-		Use:     "install <pkg>",
+		Use:     "install <pkg>[@version]",
 		Short:   "Download and install a package",
 		Args:    cobra.ExactArgs(1),
 		Aliases: []string{"i", "add", "inst"},
@@ -204,28 +259,64 @@ func main() {
 			requireRoot() // ensure running as root
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
 			_, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
 			}
 
 			if path == "" {
 				path = RecipeDirPath
 			}
 
+			if !validChecksumPolicy(ChecksumPolicy) { // --checksum-policy is applied with the config, see applySettings
+				fatalf("Invalid checksum policy %q (expected %q or %q)", ChecksumPolicy, ChecksumPolicyStrict, ChecksumPolicyPermissive)
+			}
+			if ProfileOverride != "" {
+				if err := checkProfile(ProfileOverride); err != nil {
+					fatalf("%v", err)
+				}
+			}
+
+			before, err := loadManifest()
+			if err != nil {
+				fatalf("Failed to load manifest: %v", err)
+			}
+
 			for _, pkgName := range args {
 				eyes.Infof("Processing package: %s", pkgName)
 
-				if err := install(pkgName, force, path); err != nil {
-					eyes.Errorf("Failed to install %s: %v", pkgName, err)
-					return
+				// foo@1.4.2 comes from the repository's history
+				if _, _, ok := splitVersionSpec(pkgName); ok {
+					if err := installVersion(pkgName, force, path); err != nil {
+						fatalf("Failed to install %s: %v", pkgName, err)
+					}
+					continue
+				}
+
+				if err := install(pkgName, force, path, ReasonExplicit); err != nil {
+					fatalf("Failed to install %s: %v", pkgName, err)
+				}
+			}
+
+			removed := []string{}
+			if removeBuild || CurrentSettings.RemoveBuildDeps {
+				if removed, err = removeBuildDeps(before, path); err != nil {
+					fatalf("%v", err)
+				}
+			}
+
+			if machineOutput() {
+				after, err := loadManifest()
+				if err != nil {
+					fatalf("Failed to load manifest: %v", err)
 				}
+				emitResult(InstallOutput{Installed: changedPackages(before, after), Removed: removed})
 			}
 
 		},
@@ -242,30 +333,40 @@ func main() {
 			requireRoot() // ensure running as root
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
 			_, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
 			}
 
 			if path == "" {
 				path = RecipeDirPath
 			}
 
+			if !validChecksumPolicy(ChecksumPolicy) { // --checksum-policy is applied with the config, see applySettings
+				fatalf("Invalid checksum policy %q (expected %q or %q)", ChecksumPolicy, ChecksumPolicyStrict, ChecksumPolicyPermissive)
+			}
+
 			for _, pkgName := range args {
 				eyes.Infof("Processing package: %s", pkgName)
 
 				if err := uninstall(pkgName, force, path); err != nil {
-					eyes.Errorf("Failed to uninstall %s: %v", pkgName, err)
-					return
+					fatalf("Failed to uninstall %s: %v", pkgName, err)
 				}
 			}
 
+			removed := make([]string, 0, len(args))
+			for _, pkgName := range args {
+				_, name := splitQualifiedName(pkgName)
+				removed = append(removed, name)
+			}
+			emitResult(InstallOutput{Installed: []InstalledPkg{}, Removed: removed})
+
 		},
 	}
 
@@ -274,25 +375,33 @@ func main() {
 		Use:     "sync",
 		Short:   "Syncs the package repository to the latest version.",
 		Args:    cobra.NoArgs,
-		Aliases: []string{"s", "--sync", "repo", "reposync"},
+		Aliases: []string{"s", "--sync", "reposync"},
 		Run: func(cmd *cobra.Command, args []string) {
 
 			requireRoot() // ensure running as root
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
 			_, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
 			}
 
 			if err := ensureRepoOnce(force); err != nil {
-				eyes.Fatalf("Failed to sync repositories: %v", err)
+				fatalf("Failed to sync repositories: %v", err)
+			}
+
+			if machineOutput() {
+				status, err := repoStatus()
+				if err != nil {
+					fatalf("%v", err)
+				}
+				emitResult(status)
 			}
 
 		},
@@ -307,33 +416,904 @@ func main() {
 			requireRoot()
 
 			if err := ApplyRoot(root); err != nil {
-				eyes.Fatalf("Invalid root: %v", err)
+				fatalf("Invalid root: %v", err)
 			}
 			if err := EnsureConfig(); err != nil {
-				eyes.Fatalf("Failed to ensure config: %v", err)
+				fatalf("Failed to ensure config: %v", err)
 			}
 
 			_, err := LoadConfig()
 			if err != nil {
-				eyes.Fatalf("Failed to load repositories: %v", err)
+				fatalf("Failed to load repositories: %v", err)
+			}
+
+			if path == "" {
+				path = RecipeDirPath
+			}
+
+			if !validChecksumPolicy(ChecksumPolicy) { // --checksum-policy is applied with the config, see applySettings
+				fatalf("Invalid checksum policy %q (expected %q or %q)", ChecksumPolicy, ChecksumPolicyStrict, ChecksumPolicyPermissive)
+			}
+			if ProfileOverride != "" {
+				if err := checkProfile(ProfileOverride); err != nil {
+					fatalf("%v", err)
+				}
+			}
+
+			before, err := loadManifest()
+			if err != nil {
+				fatalf("Failed to load manifest: %v", err)
+			}
+
+			result, err := updateAll(path)
+			if err != nil {
+				fatalf("Update failed: %v", err)
+			}
+			if removeBuild || CurrentSettings.RemoveBuildDeps {
+				if result.Removed, err = removeBuildDeps(before, path); err != nil {
+					fatalf("%v", err)
+				}
+			}
+			emitResult(result)
+		},
+	}
+
+	//  blink list [glob]
+	var outdated bool
+	var listFilter ListFilter
+	listCmd := &cobra.Command{
+		Use:   "list [glob]",
+		Short: "List installed packages",
+		Long: `List installed packages with their version, repository, install reason
+(explicit, dependency or build), build profile, date and size. A glob (eg. 'lib*') only lists matching names, the flags
+narrow the list further. The size is what the package installed, the files Blink
+copied to the root from its staging directory.`,
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"ls", "l"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			repos, err := LoadConfig()
+			if err != nil {
+				fatalf("Failed to load repositories: %v", err)
+			}
+
+			m, err := loadManifest()
+			if err != nil {
+				fatalf("Failed to load manifest: %v", err)
+			}
+
+			if len(args) == 1 {
+				listFilter.Pattern = args[0]
+			}
+			installed, err := filterInstalled(m, listFilter)
+			if err != nil {
+				fatalf("%v", err)
+			}
+
+			if !outdated {
+				if machineOutput() {
+					emitResult(append([]InstalledPkg{}, installed...))
+					return
+				}
+				printInstalled(installed)
+				return
+			}
+
+			result, err := checkOutdated(installed, repos, true)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if machineOutput() {
+				emitResult(append([]OutdatedPkg{}, result...))
+				return
+			}
+			printOutdated(result)
+		},
+	}
+
+	//  blink outdated
+	var noSync bool
+	outdatedCmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "List installed packages with updates available",
+		Long: `List installed packages with updates available, without prompting or
+installing anything. Exits with status 100 when there are updates 'blink update'
+would install, so it can drive cron jobs or systemd timers. Held packages and
+downgrades are listed but don't count.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			repos, err := LoadConfig()
+			if err != nil {
+				fatalf("Failed to load repositories: %v", err)
+			}
+
+			m, err := loadManifest()
+			if err != nil {
+				fatalf("Failed to load manifest: %v", err)
+			}
+
+			result, err := checkOutdated(m.Installed, repos, !noSync)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if machineOutput() {
+				emitResult(append([]OutdatedPkg{}, result...))
+			} else {
+				printOutdated(result)
+			}
+
+			if countUpdates(result) > 0 {
+				os.Exit(OutdatedExitCode)
+			}
+		},
+	}
+
+	//  blink hold <pkg> [version]
+	holdCmd := &cobra.Command{
+		Use:   "hold [pkg] [version]",
+		Short: "Keep a package at its installed version, or pin it to a version",
+		Long: `Keep a package at its installed version, or pin it to a version.
+
+A held package is skipped by 'blink update'. A pinned package is only
+ever installed or updated at the pinned version. Without arguments,
+lists the held packages.`,
+		Args: cobra.RangeArgs(0, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+
+			if len(args) == 0 {
+				holds, err := loadHolds()
+				if err != nil {
+					fatalf("Failed to load holds: %v", err)
+				}
+				if machineOutput() {
+					emitResult(holdsOutput(holds))
+					return
+				}
+				printHolds(holds)
+				return
+			}
+
+			version := ""
+			if len(args) == 2 {
+				version = args[1]
+			}
+			if err := holdPackage(args[0], version); err != nil {
+				fatalf("Failed to hold %s: %v", args[0], err)
+			}
+			emitResult(ActionOutput{Action: "hold", Targets: args[:1]})
+		},
+	}
+
+	//  blink unhold <pkg>
+	unholdCmd := &cobra.Command{
+		Use:   "unhold <pkg>...",
+		Short: "Release the hold or pin of packages",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+
+			for _, pkgName := range args {
+				if err := unholdPackage(pkgName); err != nil {
+					fatalf("Failed to unhold %s: %v", pkgName, err)
+				}
+			}
+			emitResult(ActionOutput{Action: "unhold", Targets: args})
+		},
+	}
+
+	// Repo commands for managing configured repositories
+	repoCmd := &cobra.Command{
+		Use:     "repo",
+		Short:   "Manage configured repositories",
+		Aliases: []string{"repos", "repository"},
+	}
+
+	repoStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the last verified commit of every repository",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			status, err := repoStatus()
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if machineOutput() {
+				emitResult(status)
+				return
+			}
+			printRepoStatus(status)
+		},
+	}
+
+	var repoOpts RepoAddOptions
+
+	repoAddCmd := &cobra.Command{
+		Use:   "add <name> <url|path>",
+		Short: "Add a repository",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := addRepo(context.Background(), args[0], args[1], repoOpts); err != nil {
+				fatalf("Failed to add repository: %v", err)
+			}
+			emitResult(ActionOutput{Action: "repo.add", Targets: args[:1]})
+		},
+	}
+
+	repoRemoveCmd := &cobra.Command{
+		Use:     "remove <name>",
+		Short:   "Remove a repository and its local copy",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"rm", "del", "delete"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := removeRepo(args[0]); err != nil {
+				fatalf("Failed to remove repository: %v", err)
+			}
+			emitResult(ActionOutput{Action: "repo.remove", Targets: args})
+		},
+	}
+
+	repoListCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List configured repositories",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			repos, err := LoadRepos(ConfigFilePath)
+			if err != nil {
+				fatalf("Failed to load repositories: %v", err)
+			}
+
+			if machineOutput() {
+				list := []RepoOutput{}
+				for _, repo := range reposByPriority(repos) {
+					list = append(list, repoOutput(repo))
+				}
+				emitResult(list)
+				return
+			}
+			printRepoList(repos)
+		},
+	}
+
+	repoEnableCmd := &cobra.Command{
+		Use:   "enable <name>",
+		Short: "Enable a disabled repository",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := setRepoEnabled(args[0], true); err != nil {
+				fatalf("Failed to enable repository: %v", err)
+			}
+			emitResult(ActionOutput{Action: "repo.enable", Targets: args})
+		},
+	}
+
+	repoDisableCmd := &cobra.Command{
+		Use:   "disable <name>",
+		Short: "Disable a repository without removing it",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := setRepoEnabled(args[0], false); err != nil {
+				fatalf("Failed to disable repository: %v", err)
+			}
+			emitResult(ActionOutput{Action: "repo.disable", Targets: args})
+		},
+	}
+
+	repoCmd.AddCommand(repoAddCmd, repoRemoveCmd, repoListCmd, repoEnableCmd, repoDisableCmd, repoStatusCmd)
+
+	// Config commands for reading and changing config.toml
+	configCmd := &cobra.Command{
+		Use:     "config",
+		Short:   "Show and change Blink's configuration",
+		Aliases: []string{"cfg", "conf"},
+	}
+
+	configGetCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a setting (eg. jobs, settings.proxy, repos.<name>.branch)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			value, err := configGet(args[0])
+			if err != nil {
+				fatalf("Failed to read %s: %v", args[0], err)
+			}
+			if machineOutput() {
+				emitResult(ConfigValueOutput{Key: args[0], Value: value})
+				return
+			}
+			fmt.Println(value)
+		},
+	}
+
+	configSetCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting, lists are comma separated",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := configSet(args[0], args[1]); err != nil {
+				fatalf("Failed to set %s: %v", args[0], err)
+			}
+			emitResult(ActionOutput{Action: "config.set", Targets: args[:1]})
+		},
+	}
+
+	configShowCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration, defaults included",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			if err := configShow(); err != nil {
+				fatalf("Failed to show config: %v", err)
+			}
+		},
+	}
+
+	configMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite an old config.toml in the current format",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			from, err := configMigrate()
+			if err != nil {
+				fatalf("Failed to migrate config: %v", err)
+			}
+			if from == ConfigVersion {
+				eyes.Infof("%s is already at config version %d", ConfigFilePath, ConfigVersion)
+			}
+			emitResult(ActionOutput{Action: "config.migrate", Targets: []string{ConfigFilePath}})
+		},
+	}
+
+	configCmd.AddCommand(configGetCmd, configSetCmd, configShowCmd, configMigrateCmd)
+
+	// Key commands for managing Blink's keyring of trusted public keys,
+	// repositories pin these by fingerprint in config.toml
+	keyCmd := &cobra.Command{
+		Use:     "key",
+		Short:   "Manage trusted repository and maintainer keys",
+		Aliases: []string{"keys", "keyring"},
+	}
+
+	var keyserver string
+
+	keyAddCmd := &cobra.Command{
+		Use:   "add <file|url>",
+		Short: "Add a public key to the keyring",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+
+			// proxy and download_timeout apply to fetching the key
+			if _, err := LoadConfig(); err != nil {
+				fatalf("Failed to load config: %v", err)
+			}
+
+			keys, err := addKey(context.Background(), args[0])
+			if err != nil {
+				fatalf("Failed to add key: %v", err)
+			}
+
+			for _, k := range keys {
+				eyes.Successf("Added key %s %v", k.Fingerprint, k.UserIDs)
+			}
+			eyes.Infof("Pin a key by adding its fingerprint to trusted_keys or maintainer_keys in %s", ConfigFilePath)
+			emitResult(keysOutput(keys))
+		},
+	}
+
+	keyListCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List keys in the keyring",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+
+			keys, err := listKeys(context.Background())
+			if err != nil {
+				fatalf("Failed to list keys: %v", err)
+			}
+
+			if machineOutput() {
+				emitResult(keysOutput(keys))
+				return
+			}
+
+			if len(keys) == 0 {
+				eyes.Infof("Keyring at %s is empty.", KeyringDirPath)
+				return
+			}
+
+			for _, k := range keys {
+				expires := "never"
+				if !k.Expires.IsZero() {
+					expires = k.Expires.Format("2006-01-02")
+				}
+
+				fmt.Printf(`Fingerprint: %s
+Status     : %s
+Created    : %s
+Expires    : %s
+Subkeys    : %d
+`, k.Fingerprint, k.Status(), k.Created.Format("2006-01-02"), expires, len(k.Subkeys))
+				for _, uid := range k.UserIDs {
+					fmt.Printf("User ID    : %s\n", uid)
+				}
+				fmt.Println()
+			}
+		},
+	}
+
+	keyRemoveCmd := &cobra.Command{
+		Use:     "remove <fingerprint>",
+		Short:   "Remove a key from the keyring",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"rm", "delete"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+
+			key, err := removeKey(context.Background(), args[0])
+			if err != nil {
+				fatalf("Failed to remove key: %v", err)
+			}
+			eyes.Successf("Removed key %s", key.Fingerprint)
+			emitResult(ActionOutput{Action: "key.remove", Targets: []string{key.Fingerprint}})
+
+			// tell the user which repositories just lost their trust anchor
+			repos, err := LoadRepos(ConfigFilePath)
+			if err != nil {
+				return
+			}
+			for name, repo := range repos {
+				for _, fpr := range append(repo.TrustedKeys, repo.MaintainerKeys...) {
+					if normalizeFingerprint(fpr) == key.Fingerprint {
+						eyes.Warnf("Repository %s still pins %s and will fail verification", name, key.Fingerprint)
+						break
+					}
+				}
+			}
+		},
+	}
+
+	keyRefreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Refresh keys from a keyserver (expiry, revocations, subkeys)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+
+			keys, err := refreshKeys(context.Background(), keyserver)
+			if err != nil {
+				fatalf("Failed to refresh keys: %v", err)
+			}
+
+			for _, k := range keys {
+				eyes.Infof("Refreshed %s (%s)", k.Fingerprint, k.Status())
+			}
+			emitResult(keysOutput(keys))
+		},
+	}
+
+	keyCmd.AddCommand(keyAddCmd, keyListCmd, keyRemoveCmd, keyRefreshCmd)
+
+	//  blink env <pkg>
+	envCmd := &cobra.Command{
+		Use:   "env <pkg>",
+		Short: "Show the environment a package is built with",
+		Long: `Show the environment the build commands of a package get. Builds don't
+inherit Blink's environment, each one is put together from these layers, later
+ones winning over earlier ones:
+
+  baseline  PATH, HOME, TMPDIR and LANG
+  settings  MAKEFLAGS, CFLAGS, CXXFLAGS, LDFLAGS and the proxy, from config.toml
+  profile   the build profile's flags, from --profile, [packages.<name>] or
+            settings.profile
+  vars      the recipe variables, for build scripts only
+  recipe    the recipe's build.env`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			requireRoot() // ensure running as root
+
+			if err := ApplyRoot(root); err != nil {
+				fatalf("Invalid root: %v", err)
+			}
+			if err := EnsureConfig(); err != nil {
+				fatalf("Failed to ensure config: %v", err)
+			}
+			if _, err := LoadConfig(); err != nil {
+				fatalf("Failed to load config: %v", err)
 			}
 
 			if path == "" {
 				path = RecipeDirPath
 			}
 
-			if err := updateAll(path); err != nil {
-				eyes.Fatalf("Update failed: %v", err)
+			env, err := packageEnv(path, force, args[0])
+			if err != nil {
+				fatalf("Failed to compute the environment of %s: %v", args[0], err)
+			}
+			if machineOutput() {
+				emitResult(env.Vars())
+				return
 			}
+			printBuildEnv(env)
 		},
 	}
 
+	//  blink lint <recipe|dir|repo>
+	var lintStrict bool
+	lintCmd := &cobra.Command{
+		Use:   "lint <recipe|dir|repo>",
+		Short: "Check recipes for mistakes before installing them",
+		Long: `Check recipes for schema and semantic mistakes: unknown or mistyped fields,
+build kinds, checksums, archive formats, optional dependency defaults and
+dependencies no repository provides. The target is a recipe file, a directory
+of recipes, a repository checkout (a directory with recipes/) or the name of a
+configured repository.
+
+Dependencies are resolved against the linted recipes and the last synced
+configured repositories. Exits with status 1 when errors are found (or
+warnings, with --strict), so it can run in CI. Doesn't need root.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			// configured repositories are optional, CI runners don't have any
+			repos := map[string]RepoConfig{}
+			var index *PackageIndex
+			if err := ApplyRoot(root); err != nil {
+				eyes.Warnf("Not checking against configured repositories: %v", err)
+			} else if _, err := os.Stat(ConfigFilePath); err == nil {
+				if repos, err = LoadRepos(ConfigFilePath); err != nil {
+					fatalf("Failed to load repositories: %v", err)
+				}
+				if data, err := os.ReadFile(IndexFilePath); err == nil {
+					var idx PackageIndex
+					if err := json.Unmarshal(data, &idx); err == nil {
+						index = &idx
+					}
+				}
+			}
+			if index == nil {
+				eyes.Warnf("No package index, dependencies outside the linted recipes are only warned about ('blink sync' first to check them)")
+			}
+
+			files, repo, err := lintTargetFiles(args[0], repos)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if len(files) == 0 {
+				fatalf("No recipes found in %s", args[0])
+			}
+
+			report := lintRecipes(files, repo, repos, index)
+			if machineOutput() {
+				emitResult(report)
+			} else {
+				printLintReport(report)
+			}
+
+			if report.Errors > 0 || (lintStrict && report.Warnings > 0) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	//  blink schema [--version N]
+	var schemaVersion int
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the recipe format",
+		Long: `Print the JSON Schema of a recipe schema version, the current one by default.
+Editors and CI can validate recipes against it. Doesn't need root.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			schema, err := recipeJSONSchema(schemaVersion)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if machineOutput() {
+				emitResult(schema)
+				return
+			}
+
+			data, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				fatalf("%v", err)
+			}
+			fmt.Println(string(data))
+		},
+	}
+
+	//  blink recipe migrate <recipe|dir>
+	recipeCmd := &cobra.Command{
+		Use:   "recipe",
+		Short: "Work with recipe files",
+	}
+
+	var migrateCheck bool
+	recipeMigrateCmd := &cobra.Command{
+		Use:   "migrate <recipe|dir>",
+		Short: "Rewrite recipes in the current schema version",
+		Long: `Rewrite recipes written for an older schema version in the current one. The
+target is a recipe file, a directory of recipes or a repository checkout.
+Recipes already in the current version are left untouched. With --check
+nothing is written and the exit status is 1 when a recipe needs migrating.
+Signed recipes have to be signed again after migrating. Doesn't need root.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			files, _, err := lintTargetFiles(args[0], nil)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			if len(files) == 0 {
+				fatalf("No recipes found in %s", args[0])
+			}
+
+			var results []RecipeMigration
+			failed, pending := false, false
+			for _, file := range files {
+				result, err := migrateRecipeFile(file, migrateCheck)
+				if err != nil {
+					eyes.Errorf("%v", err)
+					failed = true
+					continue
+				}
+				results = append(results, result)
+				if !result.Changed {
+					continue
+				}
+				pending = true
+
+				switch {
+				case migrateCheck:
+					eyes.Infof("%s needs migrating from schema version %d to %d", file, result.From, result.To)
+				default:
+					eyes.Infof("Migrated %s from schema version %d to %d", file, result.From, result.To)
+					if _, err := os.Stat(recipeSignaturePath(file)); err == nil {
+						eyes.Warnf("%s is now stale, sign the migrated recipe again", recipeSignaturePath(file))
+					}
+				}
+			}
+			if !pending && !failed {
+				eyes.Infof("All recipes already use schema version %d", RecipeSchemaVersion)
+			}
+
+			if machineOutput() {
+				emitResult(results)
+			}
+			if failed || (migrateCheck && pending) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	//  blink recipe convert <recipe|dir>
+	var convertTo string
+	var convertKeep, convertForce bool
+	recipeConvertCmd := &cobra.Command{
+		Use:   "convert <recipe|dir>",
+		Short: "Convert recipes between JSON and TOML",
+		Long: `Convert a recipe to the other format, next to it under the same name:
+foo.json becomes foo.toml and the other way around. A directory converts every
+recipe not already in the format given with --to. The original is removed
+unless --keep, Blink only reads the JSON recipe of a package that has both.
+Comments in TOML recipes are not carried over to JSON. Signed recipes have to
+be signed again. Doesn't need root.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if convertTo != "" && convertTo != RecipeFormatJSON && convertTo != RecipeFormatTOML {
+				fatalf("Unknown recipe format %q, expected json or toml", convertTo)
+			}
+
+			files := []string{args[0]}
+			if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+				if convertTo == "" {
+					fatalf("Converting a directory needs --to json or --to toml")
+				}
+				if files, _, err = lintTargetFiles(args[0], nil); err != nil {
+					fatalf("%v", err)
+				}
+			}
+
+			var results []RecipeConversion
+			failed := false
+			for _, file := range files {
+				to := convertTo
+				if to == "" {
+					to = RecipeFormatTOML
+					if recipeFormat(file) == RecipeFormatTOML {
+						to = RecipeFormatJSON
+					}
+				}
+				if recipeFormat(file) == to && len(files) > 1 {
+					continue
+				}
+
+				result, err := convertRecipeFile(file, to, convertKeep, convertForce)
+				if err != nil {
+					eyes.Errorf("%v", err)
+					failed = true
+					continue
+				}
+				results = append(results, result)
+				eyes.Infof("Converted %s to %s", file, result.Output)
+			}
+
+			if machineOutput() {
+				emitResult(results)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	recipeCmd.AddCommand(recipeMigrateCmd, recipeConvertCmd)
+
 	// Support command for displaying support information
 	supportCmd := &cobra.Command{
 		Use:     "support",
 		Aliases: []string{"issue", "bug", "contact", "discord", "--support", "--bug"},
 		Short:   "Show support information",
 		Run: func(cmd *cobra.Command, args []string) {
+			if machineOutput() {
+				emitResult(SupportOutput{Discord: SupportDiscordURL, Issues: SupportIssuesURL})
+				return
+			}
 			fmt.Printf("%s", SupportInformationSnippet)
 		},
 	}
@@ -348,7 +1328,10 @@ func main() {
 
 			requireRoot() // ensure running as root
 
-			clean()
+			if err := clean(); err != nil {
+				fatalf("Failed to clean: %v", err)
+			}
+			emitResult(ActionOutput{Action: "clean", Targets: []string{}})
 		},
 	}
 
@@ -360,6 +1343,10 @@ func main() {
 		Aliases: []string{"v", "ver", "--version", "-v"},
 		Short:   "Show Blink version",
 		Run: func(cmd *cobra.Command, args []string) {
+			if machineOutput() {
+				emitResult(VersionOutput{Version: CurrentBlinkVersion})
+				return
+			}
 			fmt.Printf("%s", VersionInformationSnippet)
 		},
 	}
@@ -396,6 +1383,8 @@ func main() {
 	infoCmd.Flags().BoolVarP(&force, "force", "f", false, "Force re-download")
 	infoCmd.Flags().StringVarP(&path, "path", "p", "", "Specify recipes directory")
 	infoCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	infoCmd.Flags().BoolVarP(&exact, "exact", "e", false, "Show full information for exact package names")
+	infoCmd.Flags().BoolVarP(&useRegex, "regex", "x", false, "Treat the search term as a regular expression")
 	installCmd.Flags().BoolVarP(&force, "force", "f", false, "Force reinstall")
 	installCmd.Flags().StringVarP(&path, "path", "p", "", "Specify recipes directory")
 	installCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
@@ -406,18 +1395,66 @@ func main() {
 	syncCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
 	updateCmd.Flags().StringVarP(&path, "path", "p", "", "Specify recipes directory")
 	updateCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	installCmd.Flags().StringVar(&ChecksumPolicyOverride, "checksum-policy", "", "Checksum policy for sources, strict or permissive (default from config)")
+	uninstallCmd.Flags().StringVar(&ChecksumPolicyOverride, "checksum-policy", "", "Checksum policy for sources, strict or permissive (default from config)")
+	updateCmd.Flags().StringVar(&ChecksumPolicyOverride, "checksum-policy", "", "Checksum policy for sources, strict or permissive (default from config)")
 	cleanCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	keyCmd.PersistentFlags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	repoCmd.PersistentFlags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	configCmd.PersistentFlags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	holdCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	listCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	listCmd.Flags().BoolVar(&outdated, "outdated", false, "Only list packages with another version available")
+	listCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	listCmd.Flags().BoolVar(&listFilter.Explicit, "explicit", false, "Only list packages that were installed explicitly")
+	listCmd.Flags().BoolVar(&listFilter.Deps, "deps", false, "Only list packages that were installed as dependencies")
+	listCmd.Flags().BoolVar(&listFilter.Orphans, "orphans", false, "Only list dependencies no installed package needs anymore")
+	listCmd.Flags().StringVar(&listFilter.Repo, "repo", "", "Only list packages installed from this repository")
+	outdatedCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	outdatedCmd.Flags().BoolVar(&noSync, "no-sync", false, "Compare against the last synced repositories instead of syncing first")
+	outdatedCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	installCmd.Flags().BoolVar(&AllowDowngrade, "allow-downgrade", false, "Allow replacing a package with an older version")
+	updateCmd.Flags().BoolVar(&AllowDowngrade, "allow-downgrade", false, "Also downgrade packages whose repository has an older version")
+	unholdCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	getCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	infoCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	installCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	syncCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	updateCmd.Flags().BoolVar(&AllowStaleRepos, "allow-stale", false, "Use repositories past their expiry window")
+	keyRefreshCmd.Flags().StringVar(&keyserver, "keyserver", DefaultKeyserver, "Keyserver to refresh keys from")
+	repoAddCmd.Flags().StringVarP(&repoOpts.Type, "type", "t", "", "Repository type: git, local or http (guessed when empty)")
+	repoAddCmd.Flags().StringVarP(&repoOpts.Branch, "branch", "b", "main", "Branch of a git repository")
+	repoAddCmd.Flags().StringSliceVarP(&repoOpts.Keys, "key", "k", nil, "Keyring fingerprint to pin in trusted_keys (repeatable)")
+	repoAddCmd.Flags().IntVar(&repoOpts.Priority, "priority", 0, "Repository priority, higher wins")
+	lintCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Fail on warnings too")
+	envCmd.Flags().BoolVarP(&force, "force", "f", false, "Force re-download of the recipe")
+	envCmd.Flags().StringVarP(&path, "path", "p", "", "Specify recipes directory")
+	envCmd.Flags().StringVarP(&root, "root", "r", DefaultRoot, "Specify root directory")
+	installCmd.Flags().StringVar(&ProfileOverride, "profile", "", "Build profile to use instead of the configured one")
+	installCmd.Flags().BoolVar(&removeBuild, "remove-build-deps", false, "Uninstall the build dependencies this install pulled in once it's done")
+	updateCmd.Flags().BoolVar(&removeBuild, "remove-build-deps", false, "Uninstall the build dependencies this update pulled in once it's done")
+	installCmd.Flags().BoolVar(&RunChecks, "check", false, "Run the package's test suite before installing it")
+	updateCmd.Flags().BoolVar(&RunChecks, "check", false, "Run the test suites before installing the updates")
+	updateCmd.Flags().StringVar(&ProfileOverride, "profile", "", "Build profile to use instead of the configured one")
+	envCmd.Flags().StringVar(&ProfileOverride, "profile", "", "Build profile to use instead of the configured one")
+	schemaCmd.Flags().IntVar(&schemaVersion, "version", RecipeSchemaVersion, "Recipe schema version to print")
+	recipeMigrateCmd.Flags().BoolVar(&migrateCheck, "check", false, "Only report recipes that need migrating")
+	recipeConvertCmd.Flags().StringVar(&convertTo, "to", "", "Format to convert to, json or toml (default: the other one)")
+	recipeConvertCmd.Flags().BoolVar(&convertKeep, "keep", false, "Keep the original recipe")
+	recipeConvertCmd.Flags().BoolVarP(&convertForce, "force", "f", false, "Overwrite an existing recipe in the target format")
+	rootCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", OutputText, "Output format: text, json or yaml (results on stdout, logs on stderr)")
 
 	// Add commands to cobra cli root command
-	rootCmd.AddCommand(getCmd, infoCmd, installCmd, supportCmd, versionCmd, cleanCmd, completionCmd, syncCmd, uninstallCmd, updateCmd)
+	rootCmd.AddCommand(getCmd, infoCmd, installCmd, supportCmd, versionCmd, cleanCmd, completionCmd, syncCmd, uninstallCmd, updateCmd, listCmd, outdatedCmd, holdCmd, unholdCmd, keyCmd, repoCmd, configCmd, lintCmd, schemaCmd, recipeCmd, envCmd)
 
-	// Print welcome message
-	fmt.Printf("Blink Package Manager Version: %s\n", CurrentBlinkVersion)
-	fmt.Printf("© Copyright 2025-%d Aperture OS. All rights reserved.\n", CurrentYear)
+	// Print welcome message, on stderr so it never ends up in --output json
+	fmt.Fprintf(os.Stderr, "Blink Package Manager Version: %s\n", CurrentBlinkVersion)
+	fmt.Fprintf(os.Stderr, "© Copyright 2025-%d Aperture OS. All rights reserved.\n", CurrentYear)
 
 	// Execute root command
 	if err := fang.Execute(context.Background(), rootCmd, fang.WithoutVersion(), fang.WithColorSchemeFunc(colorScheme)); err != nil {
-		eyes.Fatalf("Command Line Interface failed to run. (Is there any syntax error(s)?)\nERR: %v ", err)
+		fatalf("Command Line Interface failed to run. (Is there any syntax error(s)?)\nERR: %v ", err)
 	}
 }
 
@@ -27,6 +27,8 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -109,14 +111,19 @@ func manifestHas(name string) (*InstalledPkg, bool, error) {
 	return nil, false, nil
 }
 
-// isInstalled checks if a package is installed by name
+// isInstalled checks if a package is installed by name,
+// a "repo/pkg" qualified name is checked by its package name
 func isInstalled(pkg string) bool {
-	_, ok, err := manifestHas(pkg)
+	_, name := splitQualifiedName(pkg)
+	_, ok, err := manifestHas(name)
 	return err == nil && ok
 }
 
-// addToManifest adds a package to the manifest if it doesn't already exist
-func addToManifest(pkg PackageInfo) error {
+// addToManifest records a package in the manifest, a reinstall or
+// update replaces the existing entry so the recorded version stays right.
+// size is the number of bytes installed, 0 if unknown, and profile the build
+// profile it was built with
+func addToManifest(pkg PackageInfo, reason string, size int64, profile string) error {
 	eyes.Infof("adding %s to manifest", pkg.Name)
 
 	m, err := loadManifest()
@@ -124,22 +131,68 @@ func addToManifest(pkg PackageInfo) error {
 		return err
 	}
 
-	for _, p := range m.Installed {
+	entry := InstalledPkg{
+		Name:        pkg.Name,
+		Version:     pkg.Version,
+		Release:     int64(pkg.Release),
+		Repo:        pkg.Repo,
+		Reason:      reason,
+		InstalledAt: time.Now().UTC().Truncate(time.Second),
+		Size:        size,
+		Depends:     installedDepends(pkg, m),
+		Profile:     profile,
+	}
+
+	for i, p := range m.Installed {
 		if p.Name == pkg.Name {
-			eyes.Warnf("%s already recorded in manifest", pkg.Name)
-			return nil
+			eyes.Infof("%s already recorded in manifest, updating it to %s-%d", pkg.Name, pkg.Version, pkg.Release)
+			// reinstalling a dependency doesn't make it explicit, and an explicit package stays explicit.
+			// Installing it as a build dependency never changes what it was installed as before
+			if reason == "" || reason == ReasonBuild || p.Reason == ReasonExplicit {
+				entry.Reason = p.Reason
+			}
+			m.Installed[i] = entry
+			return saveManifest(m)
 		}
 	}
 
-	m.Installed = append(m.Installed, InstalledPkg{
-		Name:    pkg.Name,
-		Version: pkg.Version,
-		Release: int64(pkg.Release),
-	})
+	m.Installed = append(m.Installed, entry)
 
 	return saveManifest(m)
 }
 
+// installedDepends lists the installed packages pkg depends on: its mandatory
+// dependencies and whichever of its optional dependencies are installed
+func installedDepends(pkg PackageInfo, m Manifest) []string {
+	installed := make(map[string]bool, len(m.Installed))
+	for _, p := range m.Installed {
+		installed[p.Name] = true
+	}
+
+	seen := map[string]bool{}
+	var deps []string
+	add := func(dep string, onlyInstalled bool) {
+		_, name := splitQualifiedName(dep)
+		if seen[name] || (onlyInstalled && !installed[name]) {
+			return
+		}
+		seen[name] = true
+		deps = append(deps, name)
+	}
+
+	for dep := range pkg.Dependencies {
+		add(dep, false)
+	}
+	for _, group := range pkg.OptDeps {
+		for _, opt := range group.Options {
+			add(opt, true)
+		}
+	}
+
+	sort.Strings(deps)
+	return deps
+}
+
 // removeFromManifest removes a package from the manifest if it exists
 func removeFromManifest(pkg PackageInfo) error {
 	eyes.Infof("removing %s from manifest", pkg.Name)
@@ -0,0 +1,359 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Machine readable output. With --output json (or yaml) every command writes
+// exactly one document to stdout and everything else, logs, prompts and the
+// output of build commands, goes to stderr so tools don't have to scrape it.
+// The structures below are the documented format, see CONTRIBUTING.md.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// output formats of --output
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// resultOut is where emit writes, the real stdout even after setupOutput moved os.Stdout
+var resultOut io.Writer = os.Stdout
+
+// setupOutput checks --output and, for machine output, sends everything
+// written to os.Stdout to stderr instead so stdout only carries the result
+func setupOutput(format string) error {
+	switch format {
+	case OutputText:
+		return nil
+	case OutputJSON, OutputYAML:
+		resultOut = os.Stdout
+		os.Stdout = os.Stderr
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q (expected %s, %s or %s)", format, OutputText, OutputJSON, OutputYAML)
+	}
+}
+
+// machineOutput reports whether results are emitted as json or yaml instead of text
+func machineOutput() bool {
+	return OutputFormat == OutputJSON || OutputFormat == OutputYAML
+}
+
+// emit writes a command result in the selected format. YAML is produced from
+// the JSON encoding so both formats share the json field names and order
+func emit(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if OutputFormat == OutputYAML {
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return err
+		}
+		blockStyle(&node)
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return err
+		}
+		data = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+
+	_, err = fmt.Fprintf(resultOut, "%s\n", data)
+	return err
+}
+
+// blockStyle drops the flow style ({...}, [...]) and quoting JSON documents
+// parse with, the encoder still quotes strings that would read as something else
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// emitResult emits v under machine output and does nothing otherwise, for
+// commands whose text output is just their log lines
+func emitResult(v any) {
+	if !machineOutput() {
+		return
+	}
+	if err := emit(v); err != nil {
+		eyes.Fatalf("Failed to write output: %v", err)
+	}
+}
+
+// fatalf logs an error and exits, under machine output the error is emitted too
+func fatalf(format string, args ...any) {
+	if machineOutput() {
+		_ = emit(ErrorOutput{Error: fmt.Sprintf(format, args...)})
+	}
+	eyes.Fatalf(format, args...)
+}
+
+// ErrorOutput is emitted when a command fails
+type ErrorOutput struct {
+	Error string `json:"error"`
+}
+
+// ActionOutput is emitted by commands that change something without a more specific result
+type ActionOutput struct {
+	Action  string   `json:"action"`  // eg. "hold", "repo.add", "config.set"
+	Targets []string `json:"targets"` // what it was done to
+}
+
+// PackageOutput is a recipe as shown by `blink search --exact`
+type PackageOutput struct {
+	Repo         string            `json:"repo"`
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Release      int               `json:"release"`
+	Description  string            `json:"description"`
+	Author       string            `json:"author"`
+	License      string            `json:"license"`
+	Kind         string            `json:"kind"`
+	SourceURL    string            `json:"source_url"`
+	Dependencies map[string]string `json:"dependencies"`
+	BuildDeps    map[string]string `json:"build_dependencies"`
+	CheckDeps    map[string]string `json:"check_dependencies"`
+	Signature    SignatureOutput   `json:"signature"`
+	Installed    *InstalledPkg     `json:"installed"` // null when not installed
+}
+
+// SignatureOutput is the state of a recipe signature
+type SignatureOutput struct {
+	Status      string `json:"status"`
+	Signer      string `json:"signer,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// RecipeOutput is emitted by `blink get`
+type RecipeOutput struct {
+	Repo string `json:"repo"`
+	Name string `json:"name"`
+	Path string `json:"path"` // where the recipe was saved
+}
+
+// InstallOutput is emitted by `blink install` and `blink uninstall`
+type InstallOutput struct {
+	Installed []InstalledPkg `json:"installed"` // packages installed or reinstalled, dependencies included
+	Removed   []string       `json:"removed"`   // packages uninstalled
+}
+
+// UpdateOutput is the plan and result of `blink update`
+type UpdateOutput struct {
+	Planned []PlannedUpdate `json:"planned"`
+	Skipped []SkippedUpdate `json:"skipped"`
+	Updated []InstalledPkg  `json:"updated"`
+	Removed []string        `json:"removed"` // build dependencies removed afterwards
+	Aborted bool            `json:"aborted"` // the plan was declined at the prompt
+}
+
+// PlannedUpdate is a package 'blink update' is going to replace
+type PlannedUpdate struct {
+	Name      string `json:"name"`
+	Repo      string `json:"repo"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Downgrade bool   `json:"downgrade"`
+}
+
+// SkippedUpdate is a package 'blink update' left alone, and why
+type SkippedUpdate struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// HoldOutput is a held or pinned package
+type HoldOutput struct {
+	Name    string    `json:"name"`
+	Version string    `json:"version"` // pinned version, empty for a plain hold
+	Since   time.Time `json:"since"`
+}
+
+// RepoOutput is a configured repository
+type RepoOutput struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Location string `json:"location"`
+	Branch   string `json:"branch,omitempty"`
+	Priority int    `json:"priority"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// RepoStatusOutput is the verification state of a repository
+type RepoStatusOutput struct {
+	Name        string    `json:"name"`
+	Location    string    `json:"location"`
+	Enabled     bool      `json:"enabled"`
+	Commit      string    `json:"commit"` // empty if never synced
+	VerifiedAt  time.Time `json:"verified_at,omitzero"`
+	CommittedAt time.Time `json:"committed_at,omitzero"`
+	Expiry      string    `json:"expiry"`
+	Stale       bool      `json:"stale"` // past its expiry window
+}
+
+// KeyOutput is a key of the keyring
+type KeyOutput struct {
+	Fingerprint string    `json:"fingerprint"`
+	Status      string    `json:"status"`
+	UserIDs     []string  `json:"user_ids"`
+	Subkeys     []string  `json:"subkeys"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `json:"expires,omitzero"`
+}
+
+// ConfigValueOutput is emitted by `blink config get`
+type ConfigValueOutput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// VersionOutput is emitted by `blink version`
+type VersionOutput struct {
+	Version string `json:"version"`
+}
+
+// SupportOutput is emitted by `blink support`
+type SupportOutput struct {
+	Discord string `json:"discord"`
+	Issues  string `json:"issues"`
+}
+
+// holdsOutput lists holds sorted by package name
+func holdsOutput(holds Holds) []HoldOutput {
+	out := []HoldOutput{}
+	for name, hold := range holds.Packages {
+		out = append(out, HoldOutput{Name: name, Version: hold.Version, Since: hold.Since})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// repoOutput describes a configured repository
+func repoOutput(repo RepoConfig) RepoOutput {
+	out := RepoOutput{
+		Name:     repo.Name,
+		Type:     repo.Kind(),
+		Location: repo.Location(),
+		Priority: repo.Priority,
+		Enabled:  !repo.Disabled,
+	}
+	if repo.Kind() == RepoTypeGit {
+		out.Branch = repo.Ref
+	}
+	return out
+}
+
+// keysOutput describes keyring entries
+func keysOutput(keys []KeyInfo) []KeyOutput {
+	out := []KeyOutput{}
+	for _, k := range keys {
+		out = append(out, KeyOutput{
+			Fingerprint: k.Fingerprint,
+			Status:      k.Status(),
+			UserIDs:     k.UserIDs,
+			Subkeys:     k.Subkeys,
+			Created:     k.Created,
+			Expires:     k.Expires,
+		})
+	}
+	return out
+}
+
+// changedPackages returns the packages installed or reinstalled between two manifests
+func changedPackages(before, after Manifest) []InstalledPkg {
+	old := make(map[string]InstalledPkg, len(before.Installed))
+	for _, p := range before.Installed {
+		old[p.Name] = p
+	}
+
+	changed := []InstalledPkg{}
+	for _, p := range after.Installed {
+		if prev, ok := old[p.Name]; !ok || !prev.InstalledAt.Equal(p.InstalledAt) || prev.Version != p.Version || prev.Release != p.Release {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// packageOutput describes a recipe together with its signature and installed state
+func packageOutput(path string, force bool, pkgName string) (PackageOutput, error) {
+	pkg, err := fetchpkg(path, force, pkgName, true)
+	if err != nil {
+		return PackageOutput{}, err
+	}
+
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return PackageOutput{}, err
+	}
+	repo, recipePath, err := FindRepoForPackage(qualifiedName(pkg.Repo, pkg.Name), repos)
+	if err != nil {
+		return PackageOutput{}, err
+	}
+
+	sig, err := verifyRecipeSignature(context.Background(), repo, recipePath)
+	if err != nil {
+		return PackageOutput{}, err
+	}
+
+	out := PackageOutput{
+		Repo:         pkg.Repo,
+		Name:         pkg.Name,
+		Version:      pkg.Version,
+		Release:      pkg.Release,
+		Description:  pkg.Description,
+		Author:       pkg.Author,
+		License:      pkg.License,
+		Kind:         pkg.Build.Kind,
+		SourceURL:    pkg.Source.URL,
+		Dependencies: pkg.Dependencies,
+		BuildDeps:    pkg.BuildDependencies,
+		CheckDeps:    pkg.CheckDependencies,
+		Signature:    SignatureOutput{Status: sig.Status, Signer: sig.Signer, Fingerprint: sig.Fingerprint},
+	}
+	for _, deps := range []*map[string]string{&out.Dependencies, &out.BuildDeps, &out.CheckDeps} {
+		if *deps == nil {
+			*deps = map[string]string{}
+		}
+	}
+	if installed, ok, err := manifestHas(pkg.Name); err == nil && ok {
+		out.Installed = installed
+	}
+	return out, nil
+}
@@ -21,9 +21,9 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -43,7 +43,7 @@ func getpkg(pkgName string, path string) error {
 
 	// Try to acquire the lock
 	if err := lock.Acquire(); err != nil {
-		eyes.Fatalf("Could not acquire lock: %v", err)
+		return fmt.Errorf("could not acquire lock: %v", err)
 	}
 
 	// Make sure to release the lock when done
@@ -63,9 +63,6 @@ func getpkg(pkgName string, path string) error {
 		return fmt.Errorf("repositories could not be loaded.")
 	}
 
-	// make sure cache directories exist
-	checkDirAndCreate(filepath.Join(path, "recipes"))
-
 	// ensure repository is cloned/pulled
 	if err := ensureRepoOnce(false); err != nil {
 		return fmt.Errorf("failed to update repository: %v", err)
@@ -76,19 +73,24 @@ func getpkg(pkgName string, path string) error {
 		return err
 	}
 
-	destPath := filepath.Join(path, "recipes", pkgName+".json")
+	destPath := recipeCachePath(path, repo.Name, srcPath)
+
+	// make sure cache directories exist
+	checkDirAndCreate(filepath.Dir(destPath))
 
 	// handle --force behavior: overwrite if exists
 	if _, err := os.Stat(destPath); err == nil {
 		eyes.Warnf("Recipe %s already exists, overwriting...", destPath)
 	}
 
-	srcPath = filepath.Join(
-		LocalRepositoryDirPath,
-		repo.Name,
-		"recipes",
-		pkgName+".json",
-	)
+	// check the maintainer signature before the recipe ever reaches the cache
+	sig, err := verifyRecipeSignature(context.Background(), repo, srcPath)
+	if err != nil {
+		return err
+	}
+	if sig.Status == SignatureValid {
+		eyes.Infof("Recipe %s signed by %s (%s)", pkgName, sig.Signer, sig.Fingerprint)
+	}
 
 	// copy recipe from local repo cache
 	input, err := os.ReadFile(srcPath)
@@ -119,7 +121,24 @@ func fetchpkg(path string, force bool, pkgName string, quiet bool) (PackageInfo,
 		path += string(os.PathSeparator)
 	}
 
-	RecipeDirPath := filepath.Join(path, "recipes", pkgName+".json")
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return PackageInfo{}, fmt.Errorf("repositories could not be loaded.")
+	}
+
+	// ensure repository is cloned/pulled
+	if err := ensureRepoOnce(false); err != nil {
+		return PackageInfo{}, fmt.Errorf("failed to update repository: %v", err)
+	}
+
+	// resolve the repository first, the cache is kept per repository
+	repo, repoRecipePath, err := FindRepoForPackage(pkgName, repos)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	_, name := splitQualifiedName(pkgName)
+	RecipeDirPath := recipeCachePath(path, repo.Name, repoRecipePath)
 
 	if force {
 		if err := os.Remove(RecipeDirPath); err == nil {
@@ -137,39 +156,40 @@ func fetchpkg(path string, force bool, pkgName string, quiet bool) (PackageInfo,
 		if !quiet {
 			eyes.Infof("Package recipe not found. Downloading...")
 		}
-		if err := getpkg(pkgName, path); err != nil {
+		if err := getpkg(qualifiedName(repo.Name, name), path); err != nil {
 			return PackageInfo{}, err
 		}
 	}
 
-	f, err := os.Open(RecipeDirPath)
+	// the signature covers the repository's copy, so that's what gets decoded,
+	// a cached copy that went stale or was changed is replaced by it
+	sig, err := verifyRecipeSignature(context.Background(), repo, repoRecipePath)
 	if err != nil {
-		eyes.Fatalf("Failed to open package recipe.\nERR: %v", err)
-		return PackageInfo{}, fmt.Errorf("error opening file: %v", err)
+		return PackageInfo{}, err
 	}
-	defer f.Close()
-
-	var pkg PackageInfo
-	if err := json.NewDecoder(f).Decode(&pkg); err != nil {
-		eyes.Fatalf("Failed to parse JSON.\nERR: %v", err)
-		return PackageInfo{}, fmt.Errorf("error decoding JSON: %v", err)
+	verified, err := os.ReadFile(repoRecipePath)
+	if err != nil {
+		return PackageInfo{}, fmt.Errorf("failed to read recipe %s: %v", repoRecipePath, err)
 	}
-
-	if !quiet {
-
-		repos, err := LoadRepos(ConfigFilePath)
-		if err != nil {
-			return PackageInfo{}, fmt.Errorf("repositories could not be loaded.")
+	if cached, err := os.ReadFile(RecipeDirPath); err != nil || !bytes.Equal(cached, verified) {
+		if !quiet {
+			eyes.Infof("Cached recipe %s differs from the repository, refreshing it", RecipeDirPath)
 		}
-
-		// ensure repository is cloned/pulled
-		if err := ensureRepoOnce(false); err != nil {
-			return PackageInfo{}, fmt.Errorf("failed to update repository: %v", err)
+		if err := os.WriteFile(RecipeDirPath, verified, 0644); err != nil {
+			return PackageInfo{}, fmt.Errorf("failed to refresh cached recipe: %v", err)
 		}
+	}
 
-		repo, _, err := FindRepoForPackage(pkgName, repos)
-		if err != nil {
-			return PackageInfo{}, err
+	pkg, err := decodeRecipe(verified, recipeFormat(repoRecipePath))
+	if err != nil {
+		return PackageInfo{}, fmt.Errorf("invalid recipe %s: %v", repoRecipePath, err)
+	}
+	pkg.Repo = repo.Name
+
+	if !quiet {
+		signer := "-"
+		if sig.Signer != "" {
+			signer = fmt.Sprintf("%s (%s)", sig.Signer, sig.Fingerprint)
 		}
 
 		fmt.Printf(`Repository: %q (%s)
@@ -179,9 +199,12 @@ Release    :     %d
 Description: %s
 Author     :      %s
 License    :     %s
+Signature  :   %s
+Signer     :      %s
 
-`, repo.Name, repo.URL, pkg.Name, pkg.Version,
-			pkg.Release, pkg.Description, pkg.Author, pkg.License)
+`, repo.Name, repo.Location(), pkg.Name, pkg.Version,
+			pkg.Release, pkg.Description, pkg.Author, pkg.License,
+			sig.Status, signer)
 
 		eyes.Infof("Package fetching completed.")
 	}
@@ -189,11 +212,18 @@ License    :     %s
 	return pkg, nil
 }
 
+// recipeCachePath returns where the cached copy of a repository's recipe file lives,
+// it keeps the recipe's name and format
+func recipeCachePath(path, repo, recipeFile string) string {
+	return filepath.Join(path, "recipes", repo, filepath.Base(recipeFile))
+}
+
 // install function downloads, decompresses, builds, and installs a package
 // it fetches package info, downloads source, decompresses it
 // it uses the getSource, decompressSource functions for modularity and to satisfy my KISS principle
 // i wish golang had macros so i could avoid writing the same error handling code every single time and just have a single line for it
-func install(pkgName string, force bool, path string) error {
+// reason is recorded in the manifest, ReasonExplicit or ReasonDependency
+func install(pkgName string, force bool, path string, reason string) error {
 	// manifest must exist BEFORE touching it
 	if err := ensureManifest(); err != nil {
 		return err
@@ -205,6 +235,11 @@ func install(pkgName string, force bool, path string) error {
 		return err
 	}
 
+	return installPkg(pkg, force, path, reason)
+}
+
+// installPkg installs an already fetched recipe, see install
+func installPkg(pkg PackageInfo, force bool, path string, reason string) error {
 	installed, exists, err := manifestHas(pkg.Name)
 	if err != nil {
 		return err
@@ -224,16 +259,50 @@ func install(pkgName string, force bool, path string) error {
 		)
 	}
 
+	// a hold or pin must not be broken by an install or reinstall
+	if err := checkHold(pkg, installed); err != nil {
+		eyes.Errorf("%v", err)
+		return err
+	}
+
+	// reinstalling an older version than the installed one needs explicit consent
+	if exists && !AllowDowngrade {
+		available := pkgVersion(pkg.Version, pkg.Release)
+		current := pkgVersion(installed.Version, int(installed.Release))
+		if compareVersions(available, current) < 0 {
+			return fmt.Errorf("%s %s is older than the installed %s, use --allow-downgrade to downgrade it",
+				pkg.Name, available, current)
+		}
+	}
+
+	// dependencies are looked up by priority like any other package name
+	// unless a recipe qualifies them as <repo>/<name>
+	// what a build dependency needs is only needed for building too
+	depReason := ReasonDependency
+	if reason == ReasonBuild {
+		depReason = ReasonBuild
+	}
+
 	// mandatory deps
-	if err := handleMandatoryDeps(pkg.Name, path); err != nil {
+	if err := handleMandatoryDeps(pkg, path, depReason); err != nil {
 		return err
 	}
 
 	// optional deps
-	if err := handleOptionalDeps(pkg.Name, path); err != nil {
+	if err := handleOptionalDeps(pkg, path, depReason); err != nil {
 		return err
 	}
 
+	// build and check deps, precompiled packages aren't built
+	if strings.EqualFold(strings.TrimSpace(pkg.Build.Kind), "toCompile") {
+		if err := handleBuildDeps(pkg, path); err != nil {
+			return err
+		}
+	}
+
+	var size int64     // bytes installed
+	var profile string // build profile, toCompile packages only
+
 	packageKind := strings.ToLower(strings.TrimSpace(pkg.Build.Kind))
 	buildRoot := filepath.Join(BuildDirPath, pkg.Name)
 	_ = os.RemoveAll(buildRoot)
@@ -241,6 +310,22 @@ func install(pkgName string, force bool, path string) error {
 		return err
 	}
 
+	if err := checkBuildScript(pkg); err != nil {
+		return fmt.Errorf("recipe %s: %v", pkg.Name, err)
+	}
+	if err := applyBuildSystem(&pkg); err != nil {
+		return fmt.Errorf("recipe %s: %v", pkg.Name, err)
+	}
+
+	// ${version} and friends, the rest of the recipe is expanded once srcdir is known
+	vars, err := newRecipeVars(pkg, buildRoot)
+	if err != nil {
+		return err
+	}
+	if err := vars.ExpandSource(&pkg); err != nil {
+		return err
+	}
+
 	// always remember old working dir
 	oldDir, err := os.Getwd()
 	if err != nil {
@@ -254,9 +339,8 @@ func install(pkgName string, force bool, path string) error {
 			return err
 		}
 		srcFile := filepath.Join(SourceDirPath, filepath.Base(pkg.Source.URL))
-		ok, err := compareSHA256(pkg.Source.Sha256, srcFile)
-		if err != nil || !ok {
-			return fmt.Errorf("source hash mismatch for %s", srcFile)
+		if err := verifySourceChecksums(pkg, srcFile); err != nil {
+			return err
 		}
 
 		if err := decompressSource(pkg, buildRoot); err != nil {
@@ -271,72 +355,128 @@ func install(pkgName string, force bool, path string) error {
 			return err
 		}
 
-		for k, v := range pkg.Build.Env {
-			os.Setenv(k, v)
+		vars.SetSrcDir(buildDir)
+		if err := vars.ExpandBuild(&pkg); err != nil {
+			return err
 		}
 
-		for _, cmd := range pkg.Build.Prepare {
-			if err := runCmd("sh", "-c", cmd); err != nil {
-				return err
-			}
+		// every command gets this environment instead of Blink's own
+		env, err := newBuildEnv(pkg, vars, usesBuildScript(pkg))
+		if err != nil {
+			return err
 		}
-		for _, cmd := range pkg.Build.Install {
-			if err := runCmd("sh", "-c", cmd); err != nil {
+		if profile = env.Profile; profile != "" {
+			eyes.Infof("Building %s with the %s profile", pkg.Name, profile)
+		}
+
+		// build steps run as build_user when configured, installing stays root
+		cred, err := CurrentSettings.BuildCredential()
+		if err != nil {
+			return err
+		}
+		if cred != nil {
+			if err := chownTree(buildRoot, int(cred.Uid), int(cred.Gid)); err != nil {
 				return err
 			}
 		}
 
-	case "precompiled":
-		if err := safeExtractToRoot(pkg, buildRoot); err != nil {
+		// the install step installs into ${destdir}, Blink copies it to the root
+		staging := stagingDir(pkg.Name)
+		_ = os.RemoveAll(staging)
+		if err := os.MkdirAll(staging, 0755); err != nil {
 			return err
 		}
-		err = filepath.Walk(buildRoot, func(src string, info os.FileInfo, err error) error {
+		defer os.RemoveAll(staging)
+
+		// loading the script runs its top-level code, so it's done as build_user too
+		var script *BuildScript
+		if usesBuildScript(pkg) {
+			content, err := loadBuildScript(pkg)
 			if err != nil {
 				return err
 			}
-			rel, err := filepath.Rel(buildRoot, src)
-			if err != nil {
+			if script, err = newBuildScript(pkg, content, buildRoot, buildDir, env, cred); err != nil {
 				return err
 			}
-			if rel == "." {
-				return nil
-			}
+		}
 
-			target := filepath.Join("/", rel)
-			if info.IsDir() {
-				return os.MkdirAll(target, info.Mode())
+		if script != nil {
+			for _, phase := range scriptPhases {
+				var err error
+				switch phase {
+				case "check":
+					err = runChecks(pkg, script, env, cred)
+				case "package":
+					err = script.Run(phase, nil) // package() installs, like the install commands
+				default:
+					err = script.Run(phase, cred)
+				}
+				if err != nil {
+					return err
+				}
 			}
-
-			if info.Mode()&os.ModeSymlink != 0 {
-				return nil
+		} else {
+			for _, cmd := range pkg.Build.Prepare {
+				if err := runCmdAs(cred, env.Environ(), "sh", "-c", cmd); err != nil {
+					return err
+				}
 			}
-
-			// copy with immediate close
-			in, err := os.Open(src)
-			if err != nil {
-				return err
+			for _, cmd := range pkg.Build.Build {
+				if err := runCmdAs(cred, env.Environ(), "sh", "-c", cmd); err != nil {
+					return err
+				}
 			}
-			defer in.Close()
-
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			if err := runChecks(pkg, nil, env, cred); err != nil {
 				return err
 			}
-
-			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
-			if err != nil {
-				return err
+			for _, cmd := range pkg.Build.Install {
+				if err := runCmdAs(nil, env.Environ(), "sh", "-c", cmd); err != nil {
+					return err
+				}
 			}
-			defer out.Close()
+		}
 
-			_, err = io.Copy(out, in)
+		// the size is what was staged, the files of this package only
+		var files []string
+		if files, size, err = installFiles(pkg.Name, staging); err != nil {
 			return err
-		})
-		if err != nil {
+		}
+		hasUninstall := len(pkg.Build.Uninstall) > 0
+		if script != nil {
+			hasUninstall = script.Functions["uninstall"]
+		}
+		if len(files) == 0 && !hasUninstall {
+			eyes.Warnf("%s installed nothing into %s, Blink can't tell what it installed and 'blink uninstall' will leave it behind", pkg.Name, staging)
+		}
+
+	case "precompiled":
+		if err := getSource(pkg.Source.URL, force); err != nil {
+			return err
+		}
+		srcFile := filepath.Join(SourceDirPath, filepath.Base(pkg.Source.URL))
+		if err := verifySourceChecksums(pkg, srcFile); err != nil {
+			return err
+		}
+
+		// the unpacked archive is what's staged
+		if err := safeExtractToRoot(pkg, buildRoot); err != nil {
+			return err
+		}
+		if _, size, err = installFiles(pkg.Name, buildRoot); err != nil {
 			return err
 		}
 
+		// the unpacked files are the source here
+		vars.SetSrcDir(buildRoot)
+		if err := vars.ExpandBuild(&pkg); err != nil {
+			return err
+		}
+		env, err := newBuildEnv(pkg, vars, false)
+		if err != nil {
+			return err
+		}
 		for _, cmd := range pkg.Build.Install {
-			if err := runCmd("sh", "-c", cmd); err != nil {
+			if err := runCmdAs(nil, env.Environ(), "sh", "-c", cmd); err != nil {
 				return err
 			}
 		}
@@ -345,7 +485,7 @@ func install(pkgName string, force bool, path string) error {
 		return fmt.Errorf("unknown build kind: %s", pkg.Build.Kind)
 	}
 
-	return addToManifest(pkg)
+	return addToManifest(pkg, reason, size, profile)
 }
 
 /*
@@ -363,22 +503,67 @@ func uninstall(pkgName string, force bool, path string) error {
 		return err
 	}
 
-	// fetch recipe
+	_, name := splitQualifiedName(pkgName)
+	installed, exists, err := manifestHas(name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		eyes.Errorf("Package %s doesn't exist.", pkgName)
+		return fmt.Errorf("package %s doesn't exist.", pkgName)
+	}
+
+	// fetch recipe from the repository it was installed from
+	if installed.Repo != "" {
+		pkgName = qualifiedName(installed.Repo, name)
+	}
 	pkg, err := fetchpkg(path, force, pkgName, false)
 	if err != nil {
 		return err
 	}
+	if err := applyBuildSystem(&pkg); err != nil {
+		return fmt.Errorf("recipe %s: %v", pkg.Name, err)
+	}
 
-	_, exists, err := manifestHas(pkg.Name)
+	files, listed, err := readFileList(pkg.Name)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		eyes.Errorf("Package %s doesn't exist.", pkgName)
-		return fmt.Errorf("package %s doesn't exist.", pkgName)
+	// the recipe's own uninstall steps run first, they need the source
+	if usesBuildScript(pkg) || len(pkg.Build.Uninstall) > 0 {
+		if err := runUninstall(pkg, force, listed); err != nil {
+			return err
+		}
+	} else if !listed {
+		// only the manifest entry goes, like a script without uninstall()
+		eyes.Warnf("%s has no uninstall commands and Blink didn't record its files, they are left behind", pkg.Name)
+	}
+
+	if listed {
+		if len(files) > 0 {
+			eyes.Infof("Removing the %d files of %s", len(files), pkg.Name)
+		}
+		if err := removeFiles(RootDirPath, files, nil); err != nil {
+			return err
+		}
+		if err := removeFileList(pkg.Name); err != nil {
+			return err
+		}
+	}
+
+	// record install
+	if err := removeFromManifest(pkg); err != nil {
+		return err
 	}
 
+	return nil
+}
+
+// runUninstall runs the uninstall commands or uninstall() of a package in its
+// unpacked source. listed tells whether Blink removes its files anyway
+func runUninstall(pkg PackageInfo, force, listed bool) error {
 	// prepare build root
 	if err := os.MkdirAll(BuildDirPath, 0755); err != nil {
 		return err
@@ -391,20 +576,24 @@ func uninstall(pkgName string, force bool, path string) error {
 		return err
 	}
 
+	vars, err := newRecipeVars(pkg, extractRoot)
+	if err != nil {
+		return err
+	}
+	if err := vars.ExpandSource(&pkg); err != nil {
+		return err
+	}
+
 	// download source
 	if err := getSource(pkg.Source.URL, force); err != nil {
 		return err
 	}
 
 	srcFile := filepath.Join(SourceDirPath, filepath.Base(pkg.Source.URL))
-	ok, err := compareSHA256(pkg.Source.Sha256, srcFile)
-	if err != nil {
+	if err := verifySourceChecksums(pkg, srcFile); err != nil {
+		eyes.Errorf("Source verification failed for %s", srcFile)
 		return err
 	}
-	if !ok {
-		eyes.Errorf("Source hash mismatch for %s", srcFile)
-		return fmt.Errorf("source hash mismatch for %s", srcFile)
-	}
 
 	// extract
 	if err := decompressSource(pkg, extractRoot); err != nil {
@@ -420,88 +609,151 @@ func uninstall(pkgName string, force bool, path string) error {
 		return err
 	}
 
-	// env
-	for k, v := range pkg.Build.Env {
-		eyes.Infof("Setting environment variables.")
-		os.Setenv(k, v)
+	vars.SetSrcDir(buildDir)
+	if err := vars.ExpandBuild(&pkg); err != nil {
+		return err
+	}
+
+	// env, the same one the package was built with
+	env, err := newBuildEnv(pkg, vars, usesBuildScript(pkg))
+	if err != nil {
+		return err
 	}
 
-	// install
-	for _, cmd := range pkg.Build.Uninstall {
-		eyes.Infof("Uninstalling package.")
-		if err := runCmd("sh", "-c", cmd); err != nil {
+	if usesBuildScript(pkg) {
+		content, err := loadBuildScript(pkg)
+		if err != nil {
+			return err
+		}
+		script, err := newBuildScript(pkg, content, extractRoot, buildDir, env, nil)
+		if err != nil {
 			return err
 		}
+		if !script.Functions["uninstall"] && !listed {
+			eyes.Warnf("The build script of %s has no uninstall() and Blink didn't record its files, they are left behind", pkg.Name)
+		}
+		return script.Run("uninstall", nil)
 	}
 
-	// record install
-	if err := removeFromManifest(pkg); err != nil {
-		return err
+	for _, cmd := range pkg.Build.Uninstall {
+		eyes.Infof("Uninstalling package.")
+		if err := runCmdAs(nil, env.Environ(), "sh", "-c", cmd); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
 // updateAll updates all installed packages that have
 // a newer Release in the repo, using the manifest's release as
 // reference, it takes that and it checks if the Manifest's Release
-// is smaller than the repo release, if so, install the package again
-func updateAll(path string) error {
+// is smaller than the repo release, if so, install the package again.
+// The returned plan and result is what --output prints
+func updateAll(path string) (UpdateOutput, error) {
+	result := UpdateOutput{Planned: []PlannedUpdate{}, Skipped: []SkippedUpdate{}, Updated: []InstalledPkg{}, Removed: []string{}}
 
 	requireRoot()
 
 	// manifest must exist
 	if err := ensureManifest(); err != nil {
-		return err
+		return result, err
 	}
 
 	// sync repositories first
 	eyes.Infof("Syncing repositories...")
 	if err := ensureRepoOnce(false); err != nil {
-		return fmt.Errorf("failed to sync repositories: %v", err)
+		return result, fmt.Errorf("failed to sync repositories: %v", err)
 	}
 
 	m, err := loadManifest()
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	if len(m.Installed) == 0 {
 		eyes.Infof("No installed packages found.")
-		return nil
+		return result, nil
+	}
+
+	holds, err := loadHolds()
+	if err != nil {
+		return result, err
 	}
 
-	var toUpdate []InstalledPkg
+	var toUpdate, toDowngrade []InstalledPkg
+	var downgrades []PlannedUpdate // planned after the updates, in the order they run
+	skip := func(name, reason string) {
+		result.Skipped = append(result.Skipped, SkippedUpdate{Name: name, Reason: reason})
+	}
 
-	// check for updates
+	// check for updates, packages stay on the repository they were installed from
 	for _, inst := range m.Installed {
-		pkg, err := fetchpkg(path, false, inst.Name, true)
+		// always read the freshly synced recipe, a cached one may be outdated
+		pkg, err := fetchpkg(path, true, qualifiedName(inst.Repo, inst.Name), true)
 		if err != nil {
 			eyes.Warnf("Failed to fetch %s, skipping: %v", inst.Name, err)
+			skip(inst.Name, fmt.Sprintf("fetch failed: %v", err))
 			continue
 		}
 
-		if int64(pkg.Release) > inst.Release {
-			eyes.Infof(
-				"Update available: %s (%d → %d)",
-				inst.Name,
-				inst.Release,
-				pkg.Release,
-			)
+		// held packages are left alone, pinned ones only move to their pinned version
+		if hold, held := holds.Packages[inst.Name]; held {
+			if hold.Version == "" {
+				eyes.Warnf("Held: %s stays at %s (repository has %s), 'blink unhold %s' to update it",
+					inst.Name, inst.Version, pkg.Version, inst.Name)
+				skip(inst.Name, "held")
+				continue
+			}
+			if pkg.Version != hold.Version {
+				eyes.Warnf("Pinned: %s stays at %s (repository has %s), 'blink unhold %s' to update it",
+					inst.Name, hold.Version, pkg.Version, inst.Name)
+				skip(inst.Name, "pinned to "+hold.Version)
+				continue
+			}
+		}
+
+		available := pkgVersion(pkg.Version, pkg.Release)
+		current := pkgVersion(inst.Version, int(inst.Release))
+
+		planned := PlannedUpdate{Name: inst.Name, Repo: pkg.Repo, From: current.String(), To: available.String()}
+
+		switch cmp := compareVersions(available, current); {
+		case cmp > 0:
+			eyes.Infof("Update available: %s (%s → %s)", inst.Name, current, available)
 			toUpdate = append(toUpdate, inst)
-		} else {
+			result.Planned = append(result.Planned, planned)
+		case cmp < 0 && AllowDowngrade:
+			eyes.Warnf("Downgrade available: %s (%s → %s)", inst.Name, current, available)
+			toDowngrade = append(toDowngrade, inst)
+			planned.Downgrade = true
+			downgrades = append(downgrades, planned)
+		case cmp < 0:
+			eyes.Warnf("Repository has an older %s (%s → %s), use --allow-downgrade to downgrade it",
+				inst.Name, current, available)
+			skip(inst.Name, "downgrade not allowed")
+		default:
 			eyes.Infof("Up to date: %s", inst.Name)
 		}
 	}
 
-	if len(toUpdate) == 0 {
+	result.Planned = append(result.Planned, downgrades...)
+
+	if len(toUpdate) == 0 && len(toDowngrade) == 0 {
 		eyes.Success("All packages are up to date.")
-		return nil
+		return result, nil
 	}
 
-	eyes.Warnf("Packages to update: %d", len(toUpdate))
-	for _, p := range toUpdate {
-		fmt.Printf(" - %s\n", p.Name)
+	if len(toUpdate) > 0 {
+		eyes.Warnf("Packages to update: %d", len(toUpdate))
+		for _, p := range toUpdate {
+			fmt.Printf(" - %s\n", p.Name)
+		}
+	}
+	if len(toDowngrade) > 0 {
+		eyes.Warnf("Packages to DOWNGRADE: %d", len(toDowngrade))
+		for _, p := range toDowngrade {
+			fmt.Printf(" - %s\n", p.Name)
+		}
 	}
 
 	eyes.Warn("Proceed with update? [ (Y)es / (N)o ]: ")
@@ -511,16 +763,23 @@ func updateAll(path string) error {
 	switch normalizeYesNo(input) {
 	case "no":
 		eyes.Infof("Update aborted by user.")
-		return nil
+		result.Aborted = true
+		return result, nil
 	}
 
+	toUpdate = append(toUpdate, toDowngrade...)
+
 	// perform updates
 	for _, p := range toUpdate {
 		eyes.Infof("Updating %s", p.Name)
-		if err := install(p.Name, true, path); err != nil {
-			return fmt.Errorf("failed to update %s: %v", p.Name, err)
+		err := install(qualifiedName(p.Repo, p.Name), true, path, p.Reason)
+		if after, lerr := loadManifest(); lerr == nil {
+			result.Updated = changedPackages(m, after)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to update %s: %v", p.Name, err)
 		}
 	}
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,163 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Build profiles. A profile is a named set of compiler flags and a job count,
+// [profiles.<name>] tables in config.toml define them next to the built-in
+// release, debug and hardened ones (a table with the same name replaces a
+// built-in one). The profile of a build is, first match wins:
+//
+//  1. --profile on the command line
+//  2. profile in the package's [packages.<name>] table
+//  3. profile in [settings]
+//
+// Without any, builds only get the flags from [settings]. The profile a package
+// was built with is recorded in the manifest.
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BuildProfile holds a [profiles.<name>] table, empty values keep what [settings] says
+type BuildProfile struct {
+	CFlags    string `toml:"cflags,omitempty"`    // CFLAGS, and CXXFLAGS unless cxxflags is set
+	CXXFlags  string `toml:"cxxflags,omitempty"`  // CXXFLAGS
+	LDFlags   string `toml:"ldflags,omitempty"`   // LDFLAGS
+	MakeFlags string `toml:"makeflags,omitempty"` // added to MAKEFLAGS after -j<jobs>
+	RustFlags string `toml:"rustflags,omitempty"` // RUSTFLAGS
+	Jobs      int    `toml:"jobs,omitzero"`       // Parallel build jobs, 0 means settings.jobs
+}
+
+// PackageConfig holds a [packages.<name>] table, options for a single package
+type PackageConfig struct {
+	Profile   string `toml:"profile,omitempty"`    // Build profile of the package, instead of settings.profile
+	SkipCheck bool   `toml:"skip_check,omitempty"` // Never run its check phase, for known-flaky test suites
+}
+
+// BuiltinProfiles returns the profiles every Blink knows about
+func BuiltinProfiles() map[string]BuildProfile {
+	return map[string]BuildProfile{
+		"release": {
+			CFlags:    "-O2 -pipe",
+			LDFlags:   "-Wl,-O1,--as-needed",
+			RustFlags: "-C opt-level=3",
+		},
+		"debug": {
+			CFlags:    "-O0 -g -pipe",
+			RustFlags: "-C opt-level=0 -C debuginfo=2",
+		},
+		"hardened": {
+			CFlags:    "-O2 -pipe -fstack-protector-strong -fstack-clash-protection -D_FORTIFY_SOURCE=2 -fPIE",
+			LDFlags:   "-Wl,-O1,--as-needed,-z,relro,-z,now -pie",
+			RustFlags: "-C opt-level=3 -C relocation-model=pie",
+		},
+	}
+}
+
+// profileNameRe matches profile names, which are part of dotted config keys
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validProfileName checks the name of a [profiles.<name>] table
+func validProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (letters, digits, '_' and '-' only)", name)
+	}
+	return nil
+}
+
+// mergeProfiles returns the built-in profiles with the configured ones on top
+func mergeProfiles(configured map[string]BuildProfile) map[string]BuildProfile {
+	profiles := BuiltinProfiles()
+	for name, p := range configured {
+		profiles[name] = p
+	}
+	return profiles
+}
+
+// checkProfile returns an error unless name is a known profile
+func checkProfile(name string) error {
+	if _, ok := BuildProfiles[name]; !ok {
+		return fmt.Errorf("unknown build profile %q (known: %s)", name, strings.Join(sortedKeys(BuildProfiles), ", "))
+	}
+	return nil
+}
+
+// packageProfile returns the name of the profile pkgName is built with, empty for none
+func packageProfile(pkgName string) (string, error) {
+	name := ProfileOverride
+	if name == "" {
+		name = PackageConfigs[pkgName].Profile
+	}
+	if name == "" {
+		name = CurrentSettings.Profile
+	}
+	if name == "" {
+		return "", nil
+	}
+	if err := checkProfile(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// JobCount returns the number of parallel build jobs with this profile
+func (p BuildProfile) JobCount(s Settings) int {
+	if p.Jobs > 0 {
+		return p.Jobs
+	}
+	return s.JobCount()
+}
+
+// BuildEnv returns the environment the profile adds to a build, on top of
+// the one from the settings s
+func (p BuildProfile) BuildEnv(s Settings) map[string]string {
+	env := map[string]string{}
+	if p.Jobs > 0 || p.MakeFlags != "" {
+		env["MAKEFLAGS"] = strings.TrimSpace(fmt.Sprintf("-j%d %s", p.JobCount(s), p.MakeFlags))
+	}
+	if p.Jobs > 0 {
+		env["CARGO_BUILD_JOBS"] = fmt.Sprint(p.Jobs)
+	}
+	if p.CFlags != "" {
+		env["CFLAGS"] = p.CFlags
+		env["CXXFLAGS"] = p.CFlags
+	}
+	if p.CXXFlags != "" {
+		env["CXXFLAGS"] = p.CXXFlags
+	}
+	if p.LDFlags != "" {
+		env["LDFLAGS"] = p.LDFlags
+	}
+	if p.RustFlags != "" {
+		env["RUSTFLAGS"] = p.RustFlags
+	}
+	return env
+}
+
+// buildJobs returns the number of parallel jobs pkgName is built with
+func buildJobs(pkgName string) (int, error) {
+	name, err := packageProfile(pkgName)
+	if err != nil || name == "" {
+		return CurrentSettings.JobCount(), err
+	}
+	return BuildProfiles[name].JobCount(CurrentSettings), nil
+}
@@ -0,0 +1,486 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Recipe format versions. Recipes carry a schema_version, Blink reads every
+// version it knows by migrating older recipes in memory, one version at a time,
+// before decoding them strictly into PackageInfo. `blink recipe migrate`
+// rewrites them on disk and `blink schema` prints the JSON Schema of a version.
+//
+// New optional fields just go into PackageInfo. Changing what existing recipes
+// mean needs a new version: bump RecipeSchemaVersion, freeze the old struct
+// below as recipeV<N>, and add a migration from N to N+1.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// RecipeSchemaVersion is the recipe format this Blink writes
+const RecipeSchemaVersion = 3
+
+// recipeSchemaTypes are what each schema version decodes into, the JSON Schemas are generated from them
+var recipeSchemaTypes = map[int]reflect.Type{
+	1: reflect.TypeOf(recipeV1{}),
+	2: reflect.TypeOf(recipeV2{}),
+	3: reflect.TypeOf(PackageInfo{}),
+}
+
+// recipeMigrations upgrade a decoded recipe from the version they're keyed by to the next one
+var recipeMigrations = map[int]func(doc map[string]any) error{
+	1: migrateRecipeV1,
+	2: migrateRecipeV2,
+}
+
+// recipeV1 is the original, unversioned recipe format
+type recipeV1 struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Release     int    `json:"release"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	License     string `json:"license"`
+	Source      struct {
+		URL     string `json:"url"`
+		Type    string `json:"type"`
+		Sha256  string `json:"sha256"`
+		Sha512  string `json:"sha512"`
+		Blake2b string `json:"blake2b"`
+	} `json:"source"`
+	Dependencies map[string]string `json:"dependencies"`
+	OptDeps      []struct {
+		ID          int      `json:"id"`
+		Description string   `json:"description"`
+		Options     []string `json:"options"`
+		Default     string   `json:"default"`
+	} `json:"opt_dependencies"`
+	Build struct {
+		Kind      string            `json:"kind"`
+		Env       map[string]string `json:"env"`
+		Prepare   []string          `json:"prepare"`
+		Install   []string          `json:"install"`
+		Uninstall []string          `json:"uninstall"`
+	} `json:"build"`
+}
+
+// migrateRecipeV1 versions the recipe and spells its build kind the way the docs do
+func migrateRecipeV1(doc map[string]any) error {
+	if build, ok := doc["build"].(map[string]any); ok {
+		if kind, ok := build["kind"].(string); ok {
+			switch strings.ToLower(strings.TrimSpace(kind)) {
+			case "tocompile":
+				build["kind"] = "toCompile"
+			case "precompiled":
+				build["kind"] = "preCompiled"
+			}
+		}
+	}
+	doc["schema_version"] = 2
+	return nil
+}
+
+// recipeV2 is recipeV1 with a schema_version, from before recipe variables
+type recipeV2 struct {
+	SchemaVersion int    `json:"schema_version"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Release       int    `json:"release"`
+	Description   string `json:"description"`
+	Author        string `json:"author"`
+	License       string `json:"license"`
+	Source        struct {
+		URL     string `json:"url"`
+		Type    string `json:"type"`
+		Sha256  string `json:"sha256"`
+		Sha512  string `json:"sha512"`
+		Blake2b string `json:"blake2b"`
+	} `json:"source"`
+	Dependencies map[string]string `json:"dependencies"`
+	OptDeps      []struct {
+		ID          int      `json:"id"`
+		Description string   `json:"description"`
+		Options     []string `json:"options"`
+		Default     string   `json:"default"`
+	} `json:"opt_dependencies"`
+	Build struct {
+		Kind      string            `json:"kind"`
+		Env       map[string]string `json:"env"`
+		Prepare   []string          `json:"prepare"`
+		Install   []string          `json:"install"`
+		Uninstall []string          `json:"uninstall"`
+	} `json:"build"`
+}
+
+// migrateRecipeV2 escapes ${ in the places recipe variables are expanded since
+// version 3, a ${PREFIX:-/usr} that went to the shell before still does
+func migrateRecipeV2(doc map[string]any) error {
+	escape := func(v any) any {
+		if s, ok := v.(string); ok {
+			return strings.ReplaceAll(s, "${", "$${")
+		}
+		return v
+	}
+
+	if source, ok := doc["source"].(map[string]any); ok {
+		source["url"] = escape(source["url"])
+	}
+	if build, ok := doc["build"].(map[string]any); ok {
+		if env, ok := build["env"].(map[string]any); ok {
+			for k, v := range env {
+				env[k] = escape(v)
+			}
+		}
+		for _, key := range []string{"prepare", "install", "uninstall"} {
+			if cmds, ok := build[key].([]any); ok {
+				for i, cmd := range cmds {
+					cmds[i] = escape(cmd)
+				}
+			}
+		}
+	}
+	doc["schema_version"] = 3
+	return nil
+}
+
+// recipeVarRef matches a ${name} that could be a recipe variable, along with the $ before it if any
+var recipeVarRef = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// checkUnversionedVars refuses a recipe without schema_version that uses a
+// recipe variable. It's read as version 1, where ${ went to the shell, so
+// ${version} in the URL would be downloaded as it's written
+func checkUnversionedVars(doc map[string]any) error {
+	known := map[string]bool{}
+	for name := range builtinRecipeVars {
+		known[name] = true
+	}
+	if vars, ok := doc["vars"].(map[string]any); ok {
+		for name := range vars {
+			known[name] = true
+		}
+	}
+
+	check := func(path string, v any) error {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+		for _, m := range recipeVarRef.FindAllStringSubmatch(s, -1) {
+			if !strings.HasPrefix(m[0], "$$") && known[m[1]] {
+				return fmt.Errorf("%s: ${%s} is a recipe variable but the recipe has no schema_version, add \"schema_version\": %d to use it (or 2 to leave ${ to the shell)",
+					path, m[1], RecipeSchemaVersion)
+			}
+		}
+		return nil
+	}
+
+	if source, ok := doc["source"].(map[string]any); ok {
+		if err := check("$.source.url", source["url"]); err != nil {
+			return err
+		}
+	}
+	build, ok := doc["build"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if env, ok := build["env"].(map[string]any); ok {
+		for _, k := range sortedKeys(env) {
+			if err := check("$.build.env."+k, env[k]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"prepare", "build", "check", "install", "uninstall"} {
+		cmds, _ := build[key].([]any)
+		for i, cmd := range cmds {
+			if err := check(fmt.Sprintf("$.build.%s[%d]", key, i), cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recipeSchemaVersion returns the schema version a decoded recipe declares
+func recipeSchemaVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || raw == nil {
+		return 1, nil // recipes from before schema_version existed
+	}
+
+	n, ok := raw.(float64)
+	if !ok || n != float64(int(n)) || n < 1 {
+		return 0, fmt.Errorf("$.schema_version: expected a positive integer, got %s", jsonKind(raw))
+	}
+
+	version := int(n)
+	if version > RecipeSchemaVersion {
+		return 0, fmt.Errorf("recipe uses schema version %d but this Blink only knows up to version %d, update Blink", version, RecipeSchemaVersion)
+	}
+	return version, nil
+}
+
+// upgradeRecipe migrates a decoded recipe to RecipeSchemaVersion in place and
+// returns the version it started at
+func upgradeRecipe(doc map[string]any) (int, error) {
+	from, err := recipeSchemaVersion(doc)
+	if err != nil {
+		return 0, err
+	}
+	if doc["schema_version"] == nil {
+		if err := checkUnversionedVars(doc); err != nil {
+			return 0, err
+		}
+	}
+
+	for v := from; v < RecipeSchemaVersion; v++ {
+		if err := recipeMigrations[v](doc); err != nil {
+			return 0, fmt.Errorf("migrating from schema version %d: %v", v, err)
+		}
+	}
+	return from, nil
+}
+
+// parseRecipeDocument decodes a recipe into a generic document holding what
+// reading JSON gives, syntax errors carry their position
+func parseRecipeDocument(data []byte, format string) (map[string]any, error) {
+	if format == RecipeFormatTOML {
+		return parseTOMLRecipe(data)
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			line, col := offsetPosition(data, syntax.Offset)
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %v", line, col, err)
+		}
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$: expected an object, got %s", jsonKind(doc))
+	}
+	return obj, nil
+}
+
+// loadRecipeDocument parses a recipe of any known schema version and format and
+// upgrades it, returned as the current version's JSON and its decoded document
+// along with the version it was written in
+func loadRecipeDocument(data []byte, format string) ([]byte, map[string]any, int, error) {
+	doc, err := parseRecipeDocument(data, format)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	from, err := upgradeRecipe(doc)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if from == RecipeSchemaVersion && format == RecipeFormatJSON {
+		return data, doc, from, nil
+	}
+
+	// decode the upgraded recipe again so it holds what reading JSON gives, float64 numbers and all
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if doc, err = parseRecipeDocument(upgraded, RecipeFormatJSON); err != nil {
+		return nil, nil, 0, err
+	}
+	return upgraded, doc, from, nil
+}
+
+// decodeRecipe reads a recipe of any known schema version, in JSON or TOML,
+// into PackageInfo. Unknown fields and mistyped values are errors naming their JSON path
+func decodeRecipe(data []byte, format string) (PackageInfo, error) {
+	upgraded, doc, _, err := loadRecipeDocument(data, format)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	var pkg PackageInfo
+	dec := json.NewDecoder(bytes.NewReader(upgraded))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&pkg); err != nil {
+		// the decoder only names the first problem and not where it is, the linter does both
+		if problems := recipeSchemaProblems(doc); len(problems) > 0 {
+			return PackageInfo{}, errors.New(strings.Join(problems, "; "))
+		}
+		return PackageInfo{}, err
+	}
+	return pkg, nil
+}
+
+// readRecipe reads and decodes a recipe file
+func readRecipe(file string) (PackageInfo, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+	pkg, err := decodeRecipe(data, recipeFormat(file))
+	if err != nil {
+		return PackageInfo{}, fmt.Errorf("invalid recipe %s: %v", file, err)
+	}
+	return pkg, nil
+}
+
+// recipeSchemaProblems checks a current-version document against PackageInfo
+func recipeSchemaProblems(doc map[string]any) []string {
+	l := &linter{}
+	l.checkType("", "$", doc, reflect.TypeOf(PackageInfo{}))
+
+	var problems []string
+	for _, p := range l.report.Problems {
+		problems = append(problems, p.Path+": "+p.Message)
+	}
+	return problems
+}
+
+// encodeRecipe writes a recipe in format the way `blink recipe migrate` and
+// `blink recipe convert` leave it
+func encodeRecipe(pkg PackageInfo, format string) ([]byte, error) {
+	if format == RecipeFormatTOML {
+		return encodeTOMLRecipe(pkg)
+	}
+
+	// commands are full of > and &, keep them readable
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pkg); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// required fields, by JSON path, for the generated schemas
+var recipeRequired = map[string][]string{
+	"$":        {"name", "version", "source", "build"},
+	"$.source": {"url"},
+	"$.build":  {"kind"},
+}
+
+// recipeJSONSchema generates the JSON Schema (draft 2020-12) of a recipe schema version
+func recipeJSONSchema(version int) (map[string]any, error) {
+	t, ok := recipeSchemaTypes[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown recipe schema version %d (known: 1 to %d)", version, RecipeSchemaVersion)
+	}
+
+	schema := jsonSchemaFor(t, "$")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = fmt.Sprintf("Blink recipe, schema version %d", version)
+	if props, ok := schema["properties"].(map[string]any); ok && version > 1 {
+		props["schema_version"] = map[string]any{"const": version}
+		schema["required"] = append([]string{"schema_version"}, recipeRequired["$"]...)
+	}
+	return schema, nil
+}
+
+// jsonSchemaFor describes a Go type the way encoding/json reads it
+func jsonSchemaFor(t reflect.Type, path string) map[string]any {
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			props[name] = jsonSchemaFor(t.Field(i).Type, jsonPathKey(path, name))
+		}
+		schema := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+		if required, ok := recipeRequired[path]; ok {
+			schema["required"] = required
+		}
+		return schema
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaFor(t.Elem(), path+"[*]")}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": jsonSchemaFor(t.Elem(), path+"[*]")}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// RecipeMigration is the result of migrating one recipe file
+type RecipeMigration struct {
+	File    string `json:"file"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	Changed bool   `json:"changed"`
+}
+
+// migrateRecipeFile upgrades a recipe file to RecipeSchemaVersion, with check
+// it only reports whether it would change
+func migrateRecipeFile(file string, check bool) (RecipeMigration, error) {
+	result := RecipeMigration{File: file, To: RecipeSchemaVersion}
+	format := recipeFormat(file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return result, err
+	}
+	doc, err := parseRecipeDocument(data, format)
+	if err != nil {
+		return result, fmt.Errorf("%s: %v", file, err)
+	}
+	if result.From, err = recipeSchemaVersion(doc); err != nil {
+		return result, fmt.Errorf("%s: %v", file, err)
+	}
+	if result.From == RecipeSchemaVersion {
+		return result, nil
+	}
+
+	pkg, err := decodeRecipe(data, format)
+	if err != nil {
+		return result, fmt.Errorf("%s: %v", file, err)
+	}
+	result.Changed = true
+	if check {
+		return result, nil
+	}
+
+	out, err := encodeRecipe(pkg, format)
+	if err != nil {
+		return result, err
+	}
+	if format == RecipeFormatTOML && bytes.Contains(data, []byte("#")) {
+		eyes.Warnf("%s is rewritten without its comments", file)
+	}
+	if err := writeFileAtomic(file, out, 0644); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,162 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// v1Recipe is an unversioned recipe leaving ${ to the shell
+const v1Recipe = `{
+  "name": "foo",
+  "version": "1.0",
+  "release": 1,
+  "source": {"url": "https://example.com/foo-1.0.tar.gz", "sha256": "SKIP"},
+  "build": {
+    "kind": "tocompile",
+    "env": {"CC": "${CC:-cc}"},
+    "prepare": ["./configure --prefix=${PREFIX:-/usr}"],
+    "install": ["make install DESTDIR=${DESTDIR}"],
+    "uninstall": ["make uninstall"]
+  }
+}`
+
+func TestUpgradeRecipeFromV1(t *testing.T) {
+	upgraded, _, from, err := loadRecipeDocument([]byte(v1Recipe), RecipeFormatJSON)
+	if err != nil {
+		t.Fatalf("loadRecipeDocument: %v", err)
+	}
+	if from != 1 {
+		t.Errorf("from = %d, want 1", from)
+	}
+
+	pkg, err := decodeRecipe(upgraded, RecipeFormatJSON)
+	if err != nil {
+		t.Fatalf("decodeRecipe of the migrated recipe: %v", err)
+	}
+	if pkg.SchemaVersion != RecipeSchemaVersion {
+		t.Errorf("schema_version = %d, want %d", pkg.SchemaVersion, RecipeSchemaVersion)
+	}
+	if pkg.Build.Kind != "toCompile" {
+		t.Errorf("build.kind = %q, want toCompile", pkg.Build.Kind)
+	}
+	if got, want := pkg.Build.Prepare[0], "./configure --prefix=$${PREFIX:-/usr}"; got != want {
+		t.Errorf("build.prepare[0] = %q, want %q", got, want)
+	}
+
+	// the shell still gets what it got before the migration
+	vars := &RecipeVars{builtin: map[string]string{"name": "foo", "version": "1.0"}}
+	if err := vars.ExpandBuild(&pkg); err != nil {
+		t.Fatalf("ExpandBuild: %v", err)
+	}
+	want := map[string]string{
+		"env.CC":     "${CC:-cc}",
+		"prepare[0]": "./configure --prefix=${PREFIX:-/usr}",
+		"install[0]": "make install DESTDIR=${DESTDIR}",
+	}
+	got := map[string]string{
+		"env.CC":     pkg.Build.Env["CC"],
+		"prepare[0]": pkg.Build.Prepare[0],
+		"install[0]": pkg.Build.Install[0],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expanded build = %v, want %v", got, want)
+	}
+
+	// migrating the migrated recipe again changes nothing
+	again, _, from, err := loadRecipeDocument(upgraded, RecipeFormatJSON)
+	if err != nil {
+		t.Fatalf("loadRecipeDocument of the migrated recipe: %v", err)
+	}
+	if from != RecipeSchemaVersion || string(again) != string(upgraded) {
+		t.Errorf("migrated recipe changed on a second load (from version %d)", from)
+	}
+}
+
+func TestUpgradeRecipe(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     map[string]any
+		from    int
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "v2 escapes ${",
+			doc:  map[string]any{"schema_version": float64(2), "source": map[string]any{"url": "https://x/${V}.tgz"}},
+			from: 2,
+			want: map[string]any{"schema_version": 3, "source": map[string]any{"url": "https://x/$${V}.tgz"}},
+		},
+		{
+			name: "current version is left alone",
+			doc:  map[string]any{"schema_version": float64(3), "source": map[string]any{"url": "https://x/${version}.tgz"}},
+			from: 3,
+			want: map[string]any{"schema_version": float64(3), "source": map[string]any{"url": "https://x/${version}.tgz"}},
+		},
+		{
+			name:    "newer than this Blink",
+			doc:     map[string]any{"schema_version": float64(RecipeSchemaVersion + 1)},
+			wantErr: "update Blink",
+		},
+		{
+			name:    "not an integer",
+			doc:     map[string]any{"schema_version": "3"},
+			wantErr: "expected a positive integer",
+		},
+		{
+			name:    "unversioned with a recipe variable",
+			doc:     map[string]any{"source": map[string]any{"url": "https://x/${version}.tgz"}},
+			wantErr: "$.source.url: ${version} is a recipe variable",
+		},
+		{
+			name:    "unversioned with one of its own vars",
+			doc:     map[string]any{"vars": map[string]any{"v": "1"}, "build": map[string]any{"install": []any{"echo ${v}"}}},
+			wantErr: "$.build.install[0]: ${v}",
+		},
+		{
+			name: "unversioned with a shell variable",
+			doc:  map[string]any{"build": map[string]any{"install": []any{"make PREFIX=${PREFIX}"}}},
+			from: 1,
+			want: map[string]any{"schema_version": 3, "build": map[string]any{"install": []any{"make PREFIX=$${PREFIX}"}}},
+		},
+	}
+	for _, tt := range tests {
+		from, err := upgradeRecipe(tt.doc)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if from != tt.from {
+			t.Errorf("%s: from = %d, want %d", tt.name, from, tt.from)
+		}
+		if !reflect.DeepEqual(tt.doc, tt.want) {
+			t.Errorf("%s: upgraded to %v, want %v", tt.name, tt.doc, tt.want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// TOML recipes. A recipe is <name>.json or <name>.toml in a repository's
+// recipes/ directory, both hold the same schema. TOML recipes are read by
+// turning them into the document JSON would give, so everything after parsing
+// (migrations, strict decoding, lint) is shared.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Aperture-OS/eyes"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	RecipeFormatJSON = "json"
+	RecipeFormatTOML = "toml"
+)
+
+// recipeExtensions are the recipe file extensions, when a package has both the first one wins
+var recipeExtensions = []string{".json", ".toml"}
+
+// recipeFormat returns the format of a recipe file from its extension
+func recipeFormat(file string) string {
+	if strings.EqualFold(filepath.Ext(file), ".toml") {
+		return RecipeFormatTOML
+	}
+	return RecipeFormatJSON
+}
+
+// recipeName returns the package name of a recipe file, its name without the extension
+func recipeName(file string) string {
+	base := filepath.Base(file)
+	for _, ext := range recipeExtensions {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
+}
+
+// recipeFiles lists the recipes in dir, sorted so foo.json comes before foo.toml
+func recipeFiles(dir string) ([]string, error) {
+	var files []string
+	for _, ext := range recipeExtensions {
+		matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// parseTOMLRecipe decodes a TOML recipe into the document the same recipe in JSON would give
+func parseTOMLRecipe(data []byte) (map[string]any, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("invalid TOML at line %d, column %d: %s", parseErr.Position.Line, parseErr.Position.Col, parseErr.Message)
+		}
+		return nil, fmt.Errorf("invalid TOML: %v", err)
+	}
+
+	// integers come back as float64 and dates as strings, like from JSON
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOML: %v", err)
+	}
+	var converted map[string]any
+	if err := json.Unmarshal(raw, &converted); err != nil {
+		return nil, fmt.Errorf("invalid TOML: %v", err)
+	}
+	return converted, nil
+}
+
+// encodeTOMLRecipe writes a recipe as TOML. The toml encoder puts arrays on a
+// single line, which is what makes long prepare steps unreadable, so recipes
+// are written by hand: one command per line and multi-line commands as
+// multi-line strings
+func encodeTOMLRecipe(pkg PackageInfo) ([]byte, error) {
+	var b strings.Builder
+	if err := writeTOMLTable(&b, "", reflect.ValueOf(pkg)); err != nil {
+		return nil, err
+	}
+
+	// whatever is written has to read back as the same recipe
+	check, err := decodeRecipe([]byte(b.String()), RecipeFormatTOML)
+	if err != nil {
+		return nil, fmt.Errorf("encoding TOML recipe: %v", err)
+	}
+	// compared as JSON, where an empty list and no list are the same
+	want, err := encodeRecipe(pkg, RecipeFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	got, err := encodeRecipe(check, RecipeFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(got, want) {
+		return nil, fmt.Errorf("encoding TOML recipe: %s does not read back the same", pkg.Name)
+	}
+	return []byte(b.String()), nil
+}
+
+// writeTOMLTable writes the fields of a struct, its values first and its tables
+// after them, as TOML wants. Empty values are left out like omitempty would
+func writeTOMLTable(b *strings.Builder, prefix string, v reflect.Value) error {
+	t := v.Type()
+
+	type table struct {
+		key string
+		v   reflect.Value
+	}
+	var tables []table
+
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		f := v.Field(i)
+		if f.IsZero() || ((f.Kind() == reflect.Map || f.Kind() == reflect.Slice) && f.Len() == 0) {
+			continue
+		}
+
+		switch {
+		case f.Kind() == reflect.Struct, f.Kind() == reflect.Map,
+			f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Struct:
+			tables = append(tables, table{key: key, v: f})
+		default:
+			value, err := tomlValue(f)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			fmt.Fprintf(b, "%s = %s\n", tomlKey(key), value)
+		}
+	}
+
+	for _, tbl := range tables {
+		name := tomlKey(tbl.key)
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		switch tbl.v.Kind() {
+		case reflect.Struct:
+			fmt.Fprintf(b, "\n[%s]\n", name)
+			if err := writeTOMLTable(b, name, tbl.v); err != nil {
+				return err
+			}
+		case reflect.Map:
+			fmt.Fprintf(b, "\n[%s]\n", name)
+			keys := tbl.v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, k := range keys {
+				value, err := tomlValue(tbl.v.MapIndex(k))
+				if err != nil {
+					return fmt.Errorf("%s.%s: %v", name, k.String(), err)
+				}
+				fmt.Fprintf(b, "%s = %s\n", tomlKey(k.String()), value)
+			}
+		case reflect.Slice:
+			for i := 0; i < tbl.v.Len(); i++ {
+				fmt.Fprintf(b, "\n[[%s]]\n", name)
+				if err := writeTOMLTable(b, name, tbl.v.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tomlValue formats a string, number, boolean or list of them. Lists go one
+// item per line unless they're a single short item
+func tomlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			item, err := tomlValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		if len(items) == 1 && len(items[0]) < 60 && !strings.Contains(items[0], "\n") {
+			return "[" + items[0] + "]", nil
+		}
+		return "[\n  " + strings.Join(items, ",\n  ") + ",\n]", nil
+	}
+	return "", fmt.Errorf("can't write a %s as TOML", v.Type())
+}
+
+// tomlString quotes s, multi-line strings become multi-line literal strings when they can
+func tomlString(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "'''") && !strings.HasSuffix(s, "'") && literalSafe(s) {
+		return "'''\n" + s + "'''"
+	}
+	return tomlBasicString(s)
+}
+
+// tomlBasicString quotes s as a "basic" string, with escapes
+func tomlBasicString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// literalSafe reports whether s can go in a literal string, which has no escapes
+func literalSafe(s string) bool {
+	for _, r := range s {
+		if (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlKey quotes key unless it's a bare key
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return tomlBasicString(key)
+		}
+	}
+	return key
+}
+
+// RecipeConversion is the result of converting one recipe file
+type RecipeConversion struct {
+	File    string `json:"file"`
+	Output  string `json:"output"`
+	Removed bool   `json:"removed"` // the original was removed
+}
+
+// convertRecipeFile rewrites a recipe in format to, next to it under the same
+// name. The original is removed unless keep, a package with both a JSON and a
+// TOML recipe only ever uses the JSON one
+func convertRecipeFile(file, to string, keep, force bool) (RecipeConversion, error) {
+	result := RecipeConversion{File: file}
+	from := recipeFormat(file)
+	if from == to {
+		return result, fmt.Errorf("%s is already %s", file, strings.ToUpper(to))
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return result, err
+	}
+	pkg, err := decodeRecipe(data, from)
+	if err != nil {
+		return result, fmt.Errorf("%s: %v", file, err)
+	}
+	out, err := encodeRecipe(pkg, to)
+	if err != nil {
+		return result, err
+	}
+
+	result.Output = strings.TrimSuffix(file, filepath.Ext(file)) + "." + to
+	if _, err := os.Stat(result.Output); err == nil && !force {
+		return result, fmt.Errorf("%s already exists, use --force to overwrite it", result.Output)
+	}
+	if err := writeFileAtomic(result.Output, out, 0644); err != nil {
+		return result, err
+	}
+
+	if from == RecipeFormatTOML && bytes.Contains(data, []byte("#")) {
+		eyes.Warnf("Comments in %s are not carried over to %s", file, result.Output)
+	}
+	if _, err := os.Stat(recipeSignaturePath(file)); err == nil {
+		eyes.Warnf("%s signs %s only, sign %s again", recipeSignaturePath(file), filepath.Base(file), result.Output)
+	}
+
+	if !keep {
+		if err := os.Remove(file); err != nil {
+			return result, err
+		}
+		os.Remove(recipeSignaturePath(file))
+		result.Removed = true
+	}
+	return result, nil
+}
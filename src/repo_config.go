@@ -0,0 +1,299 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Editing the repository configuration from the command line (`blink repo add`,
+// `remove`, `enable`, `disable`), so nobody has to hand-edit config.toml anymore.
+// Reading and writing the file itself is done in config.go.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// repository names end up in paths and in "repo/pkg" names, keep them boring
+var repoNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// scp-like git remotes (eg. "git@github.com:owner/repo.git")
+var scpRemoteRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+@[A-Za-z0-9_.-]+:[^/].*$`)
+
+// RepoAddOptions are the optional settings of `blink repo add`
+type RepoAddOptions struct {
+	Type     string   // git, local or http, guessed from the location when empty
+	Branch   string   // branch of a git repository
+	Keys     []string // keyring fingerprints (or unique key ids) to pin in trusted_keys
+	Priority int      // repository priority
+}
+
+// validRepoName checks a repository name
+func validRepoName(name string) error {
+	if !repoNameRe.MatchString(name) {
+		return fmt.Errorf("invalid repository name %q (letters, digits, '.', '_' and '-' only)", name)
+	}
+	return nil
+}
+
+// guessRepoType picks a repository type for a location, local paths are local
+// repositories and everything else is git, http needs to be asked for explicitly
+func guessRepoType(location string) string {
+	if filepath.IsAbs(location) {
+		return RepoTypeLocal
+	}
+	return RepoTypeGit
+}
+
+// validateRepoLocation checks that location makes sense for a repository type
+func validateRepoLocation(kind, location string) error {
+	switch kind {
+	case RepoTypeLocal:
+		if !filepath.IsAbs(location) {
+			return fmt.Errorf("local repositories need an absolute path, got %q", location)
+		}
+		info, err := os.Stat(filepath.Join(location, "recipes"))
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("%s has no recipes directory", location)
+		}
+		return nil
+
+	case RepoTypeHTTP:
+		u, err := url.Parse(location)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid http repository url %q", location)
+		}
+		return nil
+
+	case RepoTypeGit:
+		if scpRemoteRe.MatchString(location) {
+			return nil
+		}
+		u, err := url.Parse(location)
+		if err != nil {
+			return fmt.Errorf("invalid git url %q: %v", location, err)
+		}
+		switch u.Scheme {
+		case "https", "http", "ssh", "git":
+			if u.Host == "" {
+				return fmt.Errorf("invalid git url %q: missing host", location)
+			}
+		case "file":
+		default:
+			return fmt.Errorf("invalid git url %q (expected https://, ssh://, git://, file:// or user@host:path)", location)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown repository type %q (expected %s, %s or %s)", kind, RepoTypeGit, RepoTypeLocal, RepoTypeHTTP)
+	}
+}
+
+// addRepo validates a new repository and appends it to config.toml
+func addRepo(ctx context.Context, name, location string, opts RepoAddOptions) error {
+	if err := validRepoName(name); err != nil {
+		return err
+	}
+
+	kind := strings.ToLower(opts.Type)
+	if kind == "" {
+		kind = guessRepoType(location)
+	}
+	if err := validateRepoLocation(kind, location); err != nil {
+		return err
+	}
+
+	// only pin keys that are really in the keyring, with their full fingerprint
+	var keys []string
+	for _, k := range opts.Keys {
+		key, err := findKey(ctx, k)
+		if err != nil {
+			return fmt.Errorf("%v (add it first with 'blink key add')", err)
+		}
+		if !key.Usable() {
+			return fmt.Errorf("key %s is %s", key.Fingerprint, key.Status())
+		}
+		keys = append(keys, key.Fingerprint)
+	}
+
+	return withLock(func() error {
+		cfg, err := loadConfigFile(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+		if _, exists := cfg.Repos[name]; exists {
+			return fmt.Errorf("repository %s already exists", name)
+		}
+
+		repo := RepoConfig{Name: name, TrustedKeys: keys, Priority: opts.Priority}
+		switch kind {
+		case RepoTypeGit:
+			repo.URL = location
+			repo.Ref = opts.Branch
+			if repo.Ref == "" {
+				repo.Ref = "main"
+			}
+		case RepoTypeLocal:
+			repo.Type = kind
+			repo.Path = filepath.Clean(location)
+		case RepoTypeHTTP:
+			repo.Type = kind
+			repo.BaseURL = location
+			if len(keys) == 0 {
+				return fmt.Errorf("http repositories need at least one --key to verify their index")
+			}
+		}
+
+		cfg.Repos[name] = repo
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		if kind == RepoTypeGit && len(keys) == 0 {
+			eyes.Warnf("Repository %s has no trusted keys, its commits will not be verified", name)
+		}
+		eyes.Infof("Added repository %s (%s), run 'blink sync' to fetch it", name, kind)
+		return nil
+	})
+}
+
+// removeRepo deletes a repository from config.toml together with its clone,
+// cached recipes and recorded state, then rebuilds the search index
+func removeRepo(name string) error {
+	if err := validRepoName(name); err != nil {
+		return err
+	}
+
+	return withLock(func() error {
+		cfg, err := loadConfigFile(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+		if _, exists := cfg.Repos[name]; !exists {
+			return fmt.Errorf("repository %s is not configured", name)
+		}
+
+		delete(cfg.Repos, name)
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		// local repositories point at the user's own directory, only
+		// what Blink downloaded itself lives under LocalRepositoryDirPath
+		clone := filepath.Join(LocalRepositoryDirPath, name)
+		for _, dir := range []string{clone, clone + ".new", filepath.Join(RecipeDirPath, "recipes", name)} {
+			if err := os.RemoveAll(dir); err != nil {
+				eyes.Warnf("Failed to remove %s: %v", dir, err)
+			}
+		}
+
+		states, err := loadRepoStates()
+		if err != nil {
+			return err
+		}
+		delete(states.Repos, name)
+		if err := saveRepoStates(states); err != nil {
+			return err
+		}
+
+		if m, err := loadManifest(); err == nil {
+			for _, p := range m.Installed {
+				if p.Repo == name {
+					eyes.Warnf("Installed package %s came from %s and won't receive updates anymore", p.Name, name)
+				}
+			}
+		}
+
+		eyes.Infof("Removed repository %s", name)
+		return refreshIndex()
+	})
+}
+
+// setRepoEnabled enables or disables a repository, disabled repositories
+// stay in the config but are neither synced nor used to find packages
+func setRepoEnabled(name string, enabled bool) error {
+	return withLock(func() error {
+		cfg, err := loadConfigFile(ConfigFilePath)
+		if err != nil {
+			return err
+		}
+		repo, exists := cfg.Repos[name]
+		if !exists {
+			return fmt.Errorf("repository %s is not configured", name)
+		}
+
+		repo.Disabled = !enabled
+		cfg.Repos[name] = repo
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		state := "enabled"
+		if !enabled {
+			state = "disabled"
+		}
+		eyes.Infof("Repository %s %s", name, state)
+		return refreshIndex()
+	})
+}
+
+// refreshIndex rebuilds the search index from the repositories already on disk, without syncing
+func refreshIndex() error {
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return err
+	}
+	return buildIndex(enabledRepos(repos))
+}
+
+// enabledRepos drops disabled repositories
+func enabledRepos(repos map[string]RepoConfig) map[string]RepoConfig {
+	enabled := make(map[string]RepoConfig, len(repos))
+	for name, repo := range repos {
+		if !repo.Disabled {
+			enabled[name] = repo
+		}
+	}
+	return enabled
+}
+
+// printRepoList shows every configured repository, highest priority first
+func printRepoList(repos map[string]RepoConfig) {
+	for _, repo := range reposByPriority(repos) {
+		state := "enabled"
+		if repo.Disabled {
+			state = "disabled"
+		}
+
+		branch := ""
+		if repo.Kind() == RepoTypeGit {
+			branch = " @ " + repo.Ref
+			if repo.Ref == "" {
+				branch = " @ HEAD"
+			}
+		}
+
+		fmt.Printf("%s [%s, priority %d, %s]\n    %s%s\n", repo.Name, repo.Kind(), repo.Priority, state, repo.Location(), branch)
+	}
+}
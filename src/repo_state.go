@@ -0,0 +1,304 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Repository state remembers the last commit Blink verified for every repository,
+// this is what protects against rollback attacks (serving an older, still validly
+// signed commit) and freeze attacks (never serving anything newer).
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+	"github.com/BurntSushi/toml"
+)
+
+// RepoState is what Blink remembers about a repository after a successful sync
+type RepoState struct {
+	Commit     string    `toml:"commit"`      // last verified commit
+	VerifiedAt time.Time `toml:"verified_at"` // when Blink verified it
+	CommitTime time.Time `toml:"commit_time"` // committer date of that commit
+}
+
+// RepoStates is the on-disk state file, keyed by repository name
+type RepoStates struct {
+	Repos map[string]RepoState `toml:"repos"`
+}
+
+// loadRepoStates reads the repository state file, a missing file is an empty state
+func loadRepoStates() (RepoStates, error) {
+	states := RepoStates{Repos: map[string]RepoState{}}
+
+	if _, err := os.Stat(RepoStateFilePath); os.IsNotExist(err) {
+		return states, nil
+	}
+
+	if _, err := toml.DecodeFile(RepoStateFilePath, &states); err != nil {
+		return states, fmt.Errorf("failed to decode repository state: %v", err)
+	}
+	if states.Repos == nil {
+		states.Repos = map[string]RepoState{}
+	}
+
+	return states, nil
+}
+
+// saveRepoStates writes the repository state file atomically
+func saveRepoStates(states RepoStates) error {
+	if err := os.MkdirAll(filepath.Dir(RepoStateFilePath), 0755); err != nil {
+		return err
+	}
+
+	tmp := RepoStateFilePath + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(states); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, RepoStateFilePath)
+}
+
+// repoStateMu serializes state file updates from repositories syncing in parallel
+var repoStateMu sync.Mutex
+
+// recordVerifiedCommit stores commit as the last verified commit of a repository
+func recordVerifiedCommit(name, commit string, commitTime time.Time) error {
+	repoStateMu.Lock()
+	defer repoStateMu.Unlock()
+
+	states, err := loadRepoStates()
+	if err != nil {
+		return err
+	}
+
+	states.Repos[name] = RepoState{
+		Commit:     commit,
+		VerifiedAt: time.Now().UTC(),
+		CommitTime: commitTime.UTC(),
+	}
+
+	return saveRepoStates(states)
+}
+
+// parseExpiry parses a repository expiry window, on top of Go durations
+// ("72h") it accepts whole days ("30d") since that's what people think in
+func parseExpiry(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid expiry %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid expiry %q", s)
+	}
+	return d, nil
+}
+
+// commitTime returns the committer date of a commit
+func commitTime(ctx context.Context, repoPath, commit string) (time.Time, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "show", "-s", "--format=%ct", commit)
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read commit time of %s: %v", commit, err)
+	}
+
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid commit time for %s: %v", commit, err)
+	}
+
+	return time.Unix(secs, 0), nil
+}
+
+// isAncestor reports whether ancestor is reachable from commit
+func isAncestor(ctx context.Context, repoPath, ancestor, commit string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "merge-base", "--is-ancestor", ancestor, commit)
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to compare %s and %s: %v", ancestor, commit, err)
+}
+
+// commitsBetween lists every commit reachable from to but not from from, oldest first
+func commitsBetween(ctx context.Context, repoPath, from, to string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--reverse", from+".."+to)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits %s..%s: %v", from, to, err)
+	}
+
+	return strings.Fields(string(out)), nil
+}
+
+// checkRepoProgress enforces that target moves the repository forward from the
+// last verified commit: it must descend from it, and when signatures are
+// configured every commit in between must be signed by a trusted key too,
+// otherwise an unsigned commit could sneak in under a signed one.
+func checkRepoProgress(ctx context.Context, name, repoPath, target string, repo RepoConfig, last RepoState) error {
+	if last.Commit == "" || last.Commit == target {
+		return nil
+	}
+
+	ok, err := isAncestor(ctx, repoPath, last.Commit, target)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("repository %s: %s does not descend from the last verified commit %s "+
+			"(rollback or rewritten history), if the history was rewritten on purpose remove [repos.%s] from %s",
+			name, target, last.Commit, name, RepoStateFilePath)
+	}
+
+	if len(repo.TrustedKeys) == 0 && repo.AllowedSigners == "" {
+		return nil
+	}
+
+	commits, err := commitsBetween(ctx, repoPath, last.Commit, target)
+	if err != nil {
+		return err
+	}
+	for _, c := range commits {
+		if err := verifyCommit(ctx, repoPath, c, repo); err != nil {
+			return fmt.Errorf("repository %s: commit %s between %s and %s failed verification: %v",
+				name, c, last.Commit, target, err)
+		}
+	}
+
+	return nil
+}
+
+// checkRepoFreshness refuses a repository whose target commit is older than its
+// expiry window, which is how a frozen mirror that stopped updating shows up
+func checkRepoFreshness(name string, repo RepoConfig, committed time.Time) error {
+	expiry, err := parseExpiry(repo.Expiry)
+	if err != nil {
+		return fmt.Errorf("repository %s: %v", name, err)
+	}
+	if expiry == 0 {
+		return nil
+	}
+
+	age := time.Since(committed)
+	if age <= expiry {
+		return nil
+	}
+
+	if AllowStaleRepos {
+		eyes.Warnf("Repository %s has not advanced in %s, using it anyway (--allow-stale)", name, age.Round(time.Hour))
+		return nil
+	}
+
+	return fmt.Errorf("repository %s has not advanced in %s (last commit %s, expiry %s), "+
+		"it may be frozen by a mirror or attacker, use --allow-stale to use it anyway",
+		name, age.Round(time.Hour), committed.Format(time.RFC3339), repo.Expiry)
+}
+
+// repoStatus returns the verification state of every configured repository, sorted by name
+func repoStatus() ([]RepoStatusOutput, error) {
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories: %v", err)
+	}
+
+	states, err := loadRepoStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repository state: %v", err)
+	}
+
+	status := []RepoStatusOutput{}
+	for _, name := range sortedRepoNames(repos) {
+		repo := repos[name]
+		state := states.Repos[name]
+
+		out := RepoStatusOutput{
+			Name:        name,
+			Location:    repo.Location(),
+			Enabled:     !repo.Disabled,
+			Commit:      state.Commit,
+			VerifiedAt:  state.VerifiedAt,
+			CommittedAt: state.CommitTime,
+			Expiry:      repo.Expiry,
+		}
+		if expiry, err := parseExpiry(repo.Expiry); err == nil && expiry > 0 && state.Commit != "" {
+			out.Stale = time.Since(state.CommitTime) > expiry
+		}
+		status = append(status, out)
+	}
+	return status, nil
+}
+
+// printRepoStatus shows repoStatus one repository per block
+func printRepoStatus(status []RepoStatusOutput) {
+	for _, s := range status {
+		lastCommit, verifiedAt, committed := "never synced", "-", "-"
+		if s.Commit != "" {
+			lastCommit = s.Commit
+			verifiedAt = s.VerifiedAt.Local().Format(time.RFC1123)
+			committed = s.CommittedAt.Local().Format(time.RFC1123)
+		}
+
+		expiry := s.Expiry
+		if expiry == "" {
+			expiry = "none"
+		}
+		if !s.Enabled {
+			lastCommit += " (disabled)"
+		}
+
+		fmt.Printf(`Repository : %s (%s)
+Verified   : %s
+Verified at: %s
+Committed  : %s
+Expiry     : %s
+`, s.Name, s.Location, lastCommit, verifiedAt, committed, expiry)
+
+		if s.Stale {
+			eyes.Warnf("Repository %s is past its expiry window", s.Name)
+		}
+		fmt.Println()
+	}
+}
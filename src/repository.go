@@ -32,75 +32,171 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
+	"sync"
+
+	"github.com/Aperture-OS/eyes"
 )
 
 // ensureRepo ensures all repositories exist, are updated, verified,
-// and checked out in a safe, reproducible way.
+// and checked out in a safe, reproducible way. The actual work is done
+// by each repository's backend (git, local or http), see backends.go.
 func ensureRepo(force bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
-
 	repos, err := LoadConfig() // defined in config.go
 	if err != nil {
 		return err
 	}
 
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout, _ := settingDuration(CurrentSettings.SyncTimeout); timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
 	// Ensure base repository directory exists
 	if err := os.MkdirAll(LocalRepositoryDirPath, 0755); err != nil {
 		return fmt.Errorf("failed to create repo dir: %v", err)
 	}
 
-	for name, repo := range repos {
-		repoPath := filepath.Join(LocalRepositoryDirPath, name)
+	states, err := loadRepoStates()
+	if err != nil {
+		return err
+	}
 
-		// Clone if missing
-		if _, err := os.Stat(repoPath); os.IsNotExist(err) {
-			if err := cloneRepo(ctx, repo.URL, repo.Ref, repoPath); err != nil {
-				return fmt.Errorf("failed to clone repository %s: %v", name, err)
-			}
-		}
+	repos = enabledRepos(repos)
+	names := sortedRepoNames(repos)
 
-		// Fetch only – never mutate working tree before verification
-		if err := fetchRepo(ctx, repoPath); err != nil {
-			return fmt.Errorf("failed to fetch repository %s: %v", name, err)
+	// sync up to `parallelism` repositories at once, errors are
+	// reported in name order so the output doesn't depend on timing
+	errs := make([]error, len(names))
+	slots := make(chan struct{}, max(CurrentSettings.Parallelism, 1))
+	var wg sync.WaitGroup
+
+	// open every backend first, so a bad one fails before any sync starts
+	backends := make([]Repository, len(names))
+	for i, name := range names {
+		backend, err := openRepository(repos[name])
+		if err != nil {
+			return err
 		}
+		backends[i] = backend
+	}
+
+	for i, name := range names {
+		backend := backends[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
 
-		// Resolve target commit
-		target, err := resolveTargetCommit(repoPath, repo.Ref)
+			errs[i] = backend.Sync(ctx, force, states.Repos[name])
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			return fmt.Errorf("failed to resolve target for %s: %v", name, err)
+			return err
+		}
+	}
+
+	// refresh the search index now that every repository is up to date
+	return buildIndex(repos)
+}
+
+// gitRepository is a repository cloned from a git URL and verified through
+// signed commits, this is the default and original repository type
+type gitRepository struct {
+	cfg RepoConfig
+}
+
+func (g *gitRepository) Name() string { return g.cfg.Name }
+
+// Dir is where the repository is cloned
+func (g *gitRepository) Dir() string {
+	return filepath.Join(LocalRepositoryDirPath, g.cfg.Name)
+}
+
+func (g *gitRepository) RecipeDir() string {
+	return filepath.Join(g.Dir(), "recipes")
+}
+
+// Sync clones or fetches the repository, verifies the target commit and
+// everything since the last verified one, then checks it out
+func (g *gitRepository) Sync(ctx context.Context, force bool, last RepoState) error {
+	name, repo, repoPath := g.cfg.Name, g.cfg, g.Dir()
+
+	// Clone if missing
+	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		if err := cloneRepo(ctx, repo.URL, repo.Ref, repoPath); err != nil {
+			return fmt.Errorf("failed to clone repository %s: %v", name, err)
 		}
+	}
+
+	// Fetch only – never mutate working tree before verification
+	if err := fetchRepo(ctx, repoPath); err != nil {
+		return fmt.Errorf("failed to fetch repository %s: %v", name, err)
+	}
+
+	// Resolve target commit
+	target, err := resolveTargetCommit(repoPath, repo.Ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target for %s: %v", name, err)
+	}
 
-		// Verify pinned hash (short or full)
-		if repo.Hash != "" {
-			if !strings.HasPrefix(target, repo.Hash) {
-				return fmt.Errorf("repository %s hash mismatch (got %s)", name, target)
-			}
+	// Verify pinned hash (short or full)
+	if repo.Hash != "" {
+		if !strings.HasPrefix(target, repo.Hash) {
+			return fmt.Errorf("repository %s hash mismatch (got %s)", name, target)
 		}
+	}
 
-		// Verify GPG signature against the exact trusted key
-		if repo.TrustedKey != "" {
-			ok, err := verifyGPGCommit(ctx, repoPath, target, filepath.Join(repoPath, repo.TrustedKey))
-			if err != nil || !ok {
-				return fmt.Errorf("repository %s failed GPG verification: %v", name, err)
-			}
+	// Verify the commit signature against the keys pinned in the config
+	if len(repo.TrustedKeys) > 0 || repo.AllowedSigners != "" {
+		if err := verifyCommit(ctx, repoPath, target, repo); err != nil {
+			return fmt.Errorf("repository %s failed signature verification: %v", name, err)
 		}
+	} else if repo.TrustedKey != "" {
+		// a key read from the repository being verified proves nothing, refuse instead of pretending
+		return fmt.Errorf("repository %s uses the deprecated trusted_key (a key file inside the repository), "+
+			"add the key with 'blink key add' and pin its fingerprint in trusted_keys instead", name)
+	}
 
-		// Checkout verified commit
-		if force {
-			if err := checkoutCommit(ctx, repoPath, target); err != nil {
-				return fmt.Errorf("failed to checkout repository %s: %v", name, err)
-			}
-		} else {
-			// Fast-forward only when not forced
-			if err := fastForwardRepo(ctx, repoPath, target); err != nil {
-				return fmt.Errorf("failed to fast-forward repository %s: %v", name, err)
-			}
+	// Never move backwards from what we verified last time
+	if err := checkRepoProgress(ctx, name, repoPath, target, repo, last); err != nil {
+		return err
+	}
+
+	// Refuse repositories that stopped advancing
+	committed, err := commitTime(ctx, repoPath, target)
+	if err != nil {
+		return err
+	}
+	if err := checkRepoFreshness(name, repo, committed); err != nil {
+		return err
+	}
+
+	// Checkout verified commit
+	if force {
+		if err := checkoutCommit(ctx, repoPath, target); err != nil {
+			return fmt.Errorf("failed to checkout repository %s: %v", name, err)
+		}
+	} else {
+		// Fast-forward only when not forced
+		if err := fastForwardRepo(ctx, repoPath, target); err != nil {
+			return fmt.Errorf("failed to fast-forward repository %s: %v", name, err)
 		}
 	}
 
+	if err := recordVerifiedCommit(name, target, committed); err != nil {
+		return fmt.Errorf("failed to record state of repository %s: %v", name, err)
+	}
+
 	return nil
 }
 
@@ -158,57 +254,120 @@ func fastForwardRepo(ctx context.Context, path, commit string) error {
 	return cmd.Run()
 }
 
-// verifyGPGCommit verifies that a specific commit is signed by the trusted key
-// Uses an isolated GNUPGHOME and enforces fingerprint matching
-func verifyGPGCommit(ctx context.Context, repoPath, commit, pubKeyPath string) (bool, error) {
-	gnupgHome, err := os.MkdirTemp("", "blink-gnupg-")
+// commit signature formats, as found in the commit object's gpgsig header
+const (
+	SignatureFormatGPG = "gpg"
+	SignatureFormatSSH = "ssh"
+)
+
+// commitSignatureFormat tells whether a commit carries a GPG or an SSH signature
+func commitSignatureFormat(ctx context.Context, repoPath, commit string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "cat-file", "commit", commit)
+	out, err := cmd.Output()
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("failed to read commit %s: %v", commit, err)
 	}
-	defer os.RemoveAll(gnupgHome)
 
-	env := append(os.Environ(), "GNUPGHOME="+gnupgHome)
-
-	// Import trusted key
-	cmd := exec.CommandContext(ctx, "gpg", "--import", pubKeyPath)
-	cmd.Env = env
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return false, fmt.Errorf("failed to import key: %v\n%s", err, string(out))
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			break // end of the commit headers
+		}
+		if !strings.HasPrefix(line, "gpgsig ") {
+			continue
+		}
+		if strings.Contains(line, "BEGIN SSH SIGNATURE") {
+			return SignatureFormatSSH, nil
+		}
+		return SignatureFormatGPG, nil
 	}
 
-	// Extract expected fingerprint
-	cmd = exec.CommandContext(ctx, "gpg", "--with-colons", "--fingerprint")
-	cmd.Env = env
-	out, err := cmd.Output()
+	return "", fmt.Errorf("commit %s is not signed", commit)
+}
+
+// verifyCommit verifies a commit with whichever trust anchor matches its signature:
+// GPG signatures against trusted_keys, SSH signatures against allowed_signers.
+// A signature type the repository has no trust configured for is a failure.
+func verifyCommit(ctx context.Context, repoPath, commit string, repo RepoConfig) error {
+	format, err := commitSignatureFormat(ctx, repoPath, commit)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	var expectedFP string
-	for _, line := range strings.Split(string(out), "\n") {
-		if strings.HasPrefix(line, "fpr:") {
-			parts := strings.Split(line, ":")
-			expectedFP = parts[9]
-			break
+	switch format {
+	case SignatureFormatSSH:
+		if repo.AllowedSigners == "" {
+			return fmt.Errorf("commit %s is SSH signed but no allowed_signers is configured", commit)
+		}
+		return verifySSHCommit(ctx, repoPath, commit, allowedSignersPath(repo.AllowedSigners))
+	default:
+		if len(repo.TrustedKeys) == 0 {
+			return fmt.Errorf("commit %s is GPG signed but no trusted_keys are configured", commit)
 		}
+		return verifyGPGCommit(ctx, repoPath, commit, repo.TrustedKeys)
 	}
-	if expectedFP == "" {
-		return false, fmt.Errorf("could not extract trusted key fingerprint")
+}
+
+// allowedSignersPath resolves an allowed_signers setting, relative paths are
+// looked up in Blink's keyring directory so they stay outside the repository
+func allowedSignersPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
 	}
+	return filepath.Join(KeyringDirPath, path)
+}
 
-	// Verify commit and capture signer fingerprint
-	cmd = exec.CommandContext(ctx, "git", "-C", repoPath, "verify-commit", "--raw", commit)
-	cmd.Env = env
-	out, err = cmd.CombinedOutput()
+// verifySSHCommit verifies that a commit carries an SSH signature made by a key
+// listed in the allowed_signers file (see ssh-keygen(1) ALLOWED SIGNERS).
+// git only reports a principal when the key is in the file, and minTrustLevel
+// makes it fail on signatures that are merely cryptographically valid.
+func verifySSHCommit(ctx context.Context, repoPath, commit, allowedSigners string) error {
+	if _, err := os.Stat(allowedSigners); err != nil {
+		return fmt.Errorf("allowed signers file %s: %v", allowedSigners, err)
+	}
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		return fmt.Errorf("ssh-keygen is required to verify SSH signatures: %v", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath,
+		"-c", "gpg.format=ssh",
+		"-c", "gpg.ssh.allowedSignersFile="+allowedSigners,
+		"-c", "gpg.minTrustLevel=fully",
+		"verify-commit", "--raw", commit)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return false, fmt.Errorf("GPG verification failed: %v\n%s", err, string(out))
+		return fmt.Errorf("SSH verification failed: %v\n%s", err, string(out))
 	}
 
-	if !strings.Contains(string(out), expectedFP) {
-		return false, fmt.Errorf("commit not signed by trusted key")
+	if !strings.Contains(string(out), "Good \"git\" signature for ") {
+		return fmt.Errorf("commit %s not signed by an allowed signer\n%s", commit, string(out))
 	}
 
-	return true, nil
+	return nil
+}
+
+// verifyGPGCommit verifies that a specific commit is signed by one of the pinned keys
+// (or one of their subkeys). Only keys from Blink's keyring are imported into an
+// isolated GNUPGHOME, nothing is ever read from the repository being verified.
+func verifyGPGCommit(ctx context.Context, repoPath, commit string, fingerprints []string) error {
+	gnupgHome, trusted, err := keyringHome(ctx, fingerprints)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(gnupgHome)
+
+	// Verify commit and capture the raw gpg status output
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "verify-commit", "--raw", commit)
+	cmd.Env = gpgEnv(gnupgHome)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("GPG verification failed: %v\n%s", err, string(out))
+	}
+
+	if _, _, err := checkGPGStatus(string(out), trusted); err != nil {
+		return fmt.Errorf("commit %s: %v", commit, err)
+	}
+
+	return nil
 }
 
 var reposEnsured bool
@@ -221,58 +380,115 @@ func ensureRepoOnce(force bool) error {
 	return ensureRepo(force)
 }
 
-// FindRepoForPackage returns the repo and recipe path for a package
-// FindRepoForPackage searches all configured repositories for the given package name.
+// FindRepoForPackage searches the configured repositories for the given package name.
+// pkgName may be qualified as "repo/pkg" to only look in that repository. Otherwise
+// the repository with the highest priority wins, ties are broken by repository name
+// so the choice never depends on map order or user input.
 // Returns the repository that contains the package and the full path to the package JSON.
 func FindRepoForPackage(pkgName string, repos map[string]RepoConfig) (RepoConfig, string, error) {
-	type match struct {
-		repo RepoConfig
-		path string
-	}
+	repoName, name := splitQualifiedName(pkgName)
+
+	if repoName != "" {
+		repo, ok := repos[repoName]
+		if !ok {
+			return RepoConfig{}, "", fmt.Errorf("repository %q is not configured", repoName)
+		}
+		repo.Name = repoName
+		if repo.Disabled {
+			return RepoConfig{}, "", fmt.Errorf("repository %q is disabled, enable it with 'blink repo enable %s'", repoName, repoName)
+		}
 
-	var matches []match
+		recipePath, ok, err := repoRecipePath(repo, name)
+		if err != nil {
+			return RepoConfig{}, "", err
+		}
+		if !ok {
+			return RepoConfig{}, "", fmt.Errorf("package %q not found in repository %q", name, repoName)
+		}
+		return repo, recipePath, nil
+	}
 
-	for _, repo := range repos {
-		recipePath := filepath.Join(
-			LocalRepositoryDirPath,
-			repo.Name,
-			"recipes",
-			pkgName+".json", // package JSON file
-		)
+	var matches []RepoConfig
+	var paths []string
 
-		if _, err := os.Stat(recipePath); err == nil {
-			matches = append(matches, match{
-				repo: repo,
-				path: recipePath,
-			})
+	for _, repo := range reposByPriority(enabledRepos(repos)) {
+		recipePath, ok, err := repoRecipePath(repo, name)
+		if err != nil {
+			return RepoConfig{}, "", err
+		}
+		if ok {
+			matches = append(matches, repo)
+			paths = append(paths, recipePath)
 		}
 	}
 
-	switch len(matches) {
-	case 0:
+	if len(matches) == 0 {
 		return RepoConfig{}, "", fmt.Errorf(
 			"package %q not found in any configured repository",
-			pkgName,
+			name,
 		)
+	}
 
-	case 1:
-		return matches[0].repo, matches[0].path, nil
-
-	default:
-		fmt.Printf("Multiple repositories provide package %q:\n\n", pkgName)
-		for i, m := range matches {
-			fmt.Printf(" [%d] %s (%s)\n", i+1, m.repo.Name, m.repo.URL)
+	if len(matches) > 1 {
+		var others []string
+		for _, m := range matches[1:] {
+			others = append(others, m.Name)
 		}
+		eyes.Infof("Package %s is provided by several repositories, using %s (priority %d) over %v, use <repo>/%s to pick one",
+			name, matches[0].Name, matches[0].Priority, others, name)
+	}
 
-		fmt.Print("\nSelect repository number: ")
+	return matches[0], paths[0], nil
+}
 
-		var choice int
-		_, err := fmt.Scanln(&choice)
-		if err != nil || choice < 1 || choice > len(matches) {
-			return RepoConfig{}, "", fmt.Errorf("invalid selection")
+// repoRecipePath returns the recipe of pkgName in repo and whether it exists
+func repoRecipePath(repo RepoConfig, pkgName string) (string, bool, error) {
+	backend, err := openRepository(repo)
+	if err != nil {
+		return "", false, err
+	}
+
+	// <pkg>.json or <pkg>.toml, in the order of recipeExtensions
+	for _, ext := range recipeExtensions {
+		recipePath := filepath.Join(backend.RecipeDir(), pkgName+ext)
+		if _, err := os.Stat(recipePath); err == nil {
+			return recipePath, true, nil
 		}
+	}
+	return filepath.Join(backend.RecipeDir(), pkgName+recipeExtensions[0]), false, nil
+}
+
+// reposByPriority returns the repositories ordered by priority (highest first),
+// repositories with the same priority are ordered by name
+func reposByPriority(repos map[string]RepoConfig) []RepoConfig {
+	ordered := make([]RepoConfig, 0, len(repos))
+	for _, name := range sortedRepoNames(repos) {
+		repo := repos[name]
+		repo.Name = name
+		ordered = append(ordered, repo)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	return ordered
+}
+
+// splitQualifiedName splits "repo/pkg" into its repository and package name,
+// an unqualified name returns an empty repository
+func splitQualifiedName(pkgName string) (string, string) {
+	if repo, name, ok := strings.Cut(pkgName, "/"); ok {
+		return repo, name
+	}
+	return "", pkgName
+}
 
-		selected := matches[choice-1]
-		return selected.repo, selected.path, nil
+// qualifiedName joins a repository and package name into "repo/pkg",
+// an empty repository returns the bare package name
+func qualifiedName(repo, pkgName string) string {
+	if repo == "" {
+		return pkgName
 	}
+	return repo + "/" + pkgName
 }
@@ -0,0 +1,203 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Script mode. Instead of command lists a recipe can carry a build script,
+// inline in build.script or as a file next to it in build.script_file, that
+// defines prepare(), build(), check(), package() and uninstall(). Every phase
+// runs in a single shell with set -e, so cd, variables and helper functions
+// carry over from one line to the next, and its output is shown and kept in
+// <pkgdir>/build.log. Recipe variables are exported to the script with the
+// build environment, ${version} and friends are plain shell variables there.
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// scriptPhases are the script functions an install runs, in order. Functions
+// a script doesn't define are skipped
+var scriptPhases = []string{"prepare", "build", "check", "package"}
+
+// scriptFunctions are all the functions Blink calls in a build script
+var scriptFunctions = append(append([]string{}, scriptPhases...), "uninstall")
+
+// usesBuildScript reports whether a recipe is in script mode
+func usesBuildScript(pkg PackageInfo) bool {
+	return pkg.Build.Script != "" || pkg.Build.ScriptFile != ""
+}
+
+// checkBuildScript rejects recipes mixing script mode with what it replaces
+func checkBuildScript(pkg PackageInfo) error {
+	switch {
+	case !usesBuildScript(pkg):
+		return nil
+	case pkg.Build.Script != "" && pkg.Build.ScriptFile != "":
+		return fmt.Errorf("build.script and build.script_file can't both be set")
+	case !strings.EqualFold(strings.TrimSpace(pkg.Build.Kind), "toCompile"):
+		return fmt.Errorf("build scripts are only for toCompile recipes")
+	case len(pkg.Build.Prepare) > 0 || len(pkg.Build.Build) > 0 || len(pkg.Build.Check) > 0 || len(pkg.Build.Install) > 0 || len(pkg.Build.Uninstall) > 0:
+		return fmt.Errorf("a recipe with a build script defines its steps as script functions, not in build.prepare, build.build, build.check, build.install or build.uninstall")
+	}
+	return nil
+}
+
+// BuildScript is a recipe's build script, ready to run in a package's build directory
+type BuildScript struct {
+	Pkg       string
+	Path      string          // the script, written into pkgdir
+	SrcDir    string          // where every phase starts
+	Env       []string        // the build environment, recipe variables included
+	LogPath   string          // every phase's output, appended
+	Functions map[string]bool // functions the script defines
+}
+
+// loadBuildScript returns the script of a recipe in script mode, reading
+// build.script_file from the recipe's repository and checking its signature
+// the way recipes are checked
+func loadBuildScript(pkg PackageInfo) (string, error) {
+	if pkg.Build.Script != "" {
+		return pkg.Build.Script, nil
+	}
+
+	repos, err := LoadRepos(ConfigFilePath)
+	if err != nil {
+		return "", err
+	}
+	repo, ok := repos[pkg.Repo]
+	if !ok {
+		return "", fmt.Errorf("repository %s of %s is not configured", pkg.Repo, pkg.Name)
+	}
+	repo.Name = pkg.Repo
+
+	backend, err := openRepository(repo)
+	if err != nil {
+		return "", err
+	}
+	path, err := scriptFilePath(backend.RecipeDir(), pkg.Build.ScriptFile)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := verifyRecipeSignature(context.Background(), repo, path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read build script of %s: %v", pkg.Name, err)
+	}
+	return string(data), nil
+}
+
+// scriptFilePath resolves build.script_file, which has to stay inside the recipes directory
+func scriptFilePath(recipeDir, scriptFile string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(scriptFile))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("build.script_file %q must be a path inside the recipes directory", scriptFile)
+	}
+	return filepath.Join(recipeDir, rel), nil
+}
+
+// newBuildScript writes script into pkgdir and finds out which functions it
+// defines, env is the build environment (see buildenv.go). Finding them sources
+// the script, so its top-level code runs as cred, the user of prepare()
+func newBuildScript(pkg PackageInfo, script, pkgdir, srcdir string, env *BuildEnv, cred *syscall.Credential) (*BuildScript, error) {
+	s := &BuildScript{
+		Pkg:       pkg.Name,
+		Path:      filepath.Join(pkgdir, "blink-build.sh"),
+		SrcDir:    srcdir,
+		Env:       env.Environ(),
+		LogPath:   filepath.Join(pkgdir, "build.log"),
+		Functions: map[string]bool{},
+	}
+	if err := os.WriteFile(s.Path, []byte(script), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write build script: %v", err)
+	}
+
+	// type says "... is a shell function" in dash and "... is a function" in bash,
+	// command -v alone can't tell a function from a program of the same name
+	probe := `. "$1" >/dev/null; for f in ` + strings.Join(scriptFunctions, " ") +
+		`; do if type "$f" 2>/dev/null | grep -q function; then echo "$f"; fi; done`
+	cmd := exec.Command("sh", "-c", probe, "sh", s.Path)
+	cmd.Env = s.Env
+	cmd.Dir = srcdir
+	if cred != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("build script of %s failed to load: %v\n%s", pkg.Name, err, stderr.String())
+	}
+	for _, f := range strings.Fields(string(out)) {
+		s.Functions[f] = true
+	}
+	return s, nil
+}
+
+// Run runs one phase in its own shell, with set -e, starting in srcdir. A nil
+// credential runs it as the current user
+func (s *BuildScript) Run(phase string, cred *syscall.Credential) error {
+	return s.run(phase, cred, s.LogPath)
+}
+
+// run is Run with the output appended to logPath
+func (s *BuildScript) run(phase string, cred *syscall.Credential, logPath string) error {
+	if !s.Functions[phase] {
+		eyes.Infof("%s: no %s() in the build script, skipping", s.Pkg, phase)
+		return nil
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	fmt.Fprintf(logFile, "==> %s() %s\n", phase, time.Now().Format(time.RFC3339))
+
+	eyes.Infof("%s: running %s()", s.Pkg, phase)
+	started := time.Now()
+
+	cmd := exec.Command("sh", "-c", `set -e; . "$1"; cd "$srcdir"; `+phase, "sh", s.Path)
+	cmd.Env = s.Env
+	cmd.Dir = s.SrcDir
+	cmd.Stdout = io.MultiWriter(os.Stdout, logFile)
+	cmd.Stderr = io.MultiWriter(os.Stderr, logFile)
+	if cred != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s() failed after %s: %v (output in %s)", s.Pkg, phase, time.Since(started).Round(time.Millisecond), err, logPath)
+	}
+	eyes.Infof("%s: %s() done in %s", s.Pkg, phase, time.Since(started).Round(time.Millisecond))
+	return nil
+}
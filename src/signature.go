@@ -0,0 +1,119 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Per-recipe signatures: a recipe can be shipped with a detached GPG signature
+// (recipes/<name>.json.sig or <name>.toml.sig) made by one of the package
+// maintainers. The signature is checked against the maintainer key fingerprints
+// listed for the repository in config.toml, whose keys live in Blink's keyring
+// outside of the repository, so a compromised git host or an unsigned merge cannot change a recipe unnoticed.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/Aperture-OS/eyes"
+)
+
+// recipe signature states, shown in `blink search`
+const (
+	SignatureValid      = "valid"      // signed by one of the repository's maintainer keys
+	SignatureInvalid    = "invalid"    // signature present but bad or made by an unknown key
+	SignatureUnsigned   = "unsigned"   // no signature next to the recipe
+	SignatureUnverified = "unverified" // signature present but the repo has no maintainer keys configured
+)
+
+// RecipeSignature is the result of checking a recipe's detached signature
+type RecipeSignature struct {
+	Status      string // one of the Signature* constants
+	Signer      string // user id of the signing key (eg. "Jane Doe <jane@example.com>")
+	Fingerprint string // primary key fingerprint of the signer
+}
+
+// recipeSignaturePath returns where the detached signature of a recipe lives
+func recipeSignaturePath(recipePath string) string {
+	return recipePath + ".sig"
+}
+
+// verifyRecipeSignature checks the detached signature of recipePath against the
+// maintainer keys of repo. It only returns an error when the recipe must not be
+// used: a bad signature, or a missing one when the repository requires them.
+// A repository with maintainer keys always does, otherwise removing the .sig
+// would be enough to slip a changed recipe through.
+func verifyRecipeSignature(ctx context.Context, repo RepoConfig, recipePath string) (RecipeSignature, error) {
+	sigPath := recipeSignaturePath(recipePath)
+
+	if _, err := os.Stat(sigPath); os.IsNotExist(err) {
+		if len(repo.MaintainerKeys) > 0 {
+			return RecipeSignature{Status: SignatureUnsigned},
+				fmt.Errorf("repository %s has maintainer_keys but %s has no signature", repo.Name, recipePath)
+		}
+		if repo.RequireSignedRecipes {
+			return RecipeSignature{Status: SignatureUnsigned},
+				fmt.Errorf("repository %s requires signed recipes but %s has no signature", repo.Name, recipePath)
+		}
+		return RecipeSignature{Status: SignatureUnsigned}, nil
+	}
+
+	if len(repo.MaintainerKeys) == 0 {
+		if repo.RequireSignedRecipes {
+			return RecipeSignature{Status: SignatureUnverified},
+				fmt.Errorf("repository %s requires signed recipes but has no maintainer_keys configured", repo.Name)
+		}
+		eyes.Warnf("Recipe %s is signed but repository %s has no maintainer keys, signature not checked", recipePath, repo.Name)
+		return RecipeSignature{Status: SignatureUnverified}, nil
+	}
+
+	sig, err := gpgVerifyDetached(ctx, repo.MaintainerKeys, sigPath, recipePath)
+	if err != nil {
+		sig.Status = SignatureInvalid
+		return sig, fmt.Errorf("recipe %s failed signature verification: %v", recipePath, err)
+	}
+
+	sig.Status = SignatureValid
+	return sig, nil
+}
+
+// gpgVerifyDetached verifies sigPath over dataPath using only the pinned keys
+// from Blink's keyring. The signer must be one of them (or one of their subkeys).
+func gpgVerifyDetached(ctx context.Context, fingerprints []string, sigPath, dataPath string) (RecipeSignature, error) {
+	gnupgHome, trusted, err := keyringHome(ctx, fingerprints)
+	if err != nil {
+		return RecipeSignature{}, err
+	}
+	defer os.RemoveAll(gnupgHome)
+
+	// Verify and read the machine readable status output
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--status-fd", "1", "--verify", sigPath, dataPath)
+	cmd.Env = gpgEnv(gnupgHome)
+	out, err := cmd.Output()
+	if err != nil {
+		return RecipeSignature{}, fmt.Errorf("GPG verification failed: %v\n%s", err, string(out))
+	}
+
+	signer, primary, err := checkGPGStatus(string(out), trusted)
+	if err != nil {
+		return RecipeSignature{}, err
+	}
+
+	return RecipeSignature{Signer: signer, Fingerprint: primary}, nil
+}
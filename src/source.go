@@ -50,7 +50,7 @@ func getSource(url string, isForce bool) error {
 
 		// Perform HTTP GET request
 
-		resp, err := http.Get(url)
+		resp, err := downloadClient().Get(url)
 		if err != nil {
 			return fmt.Errorf("failed to download recipe: %v", err)
 		}
@@ -81,6 +81,25 @@ func getSource(url string, isForce bool) error {
 	return nil
 }
 
+// downloadClient returns the HTTP client for downloads, limited by download_timeout
+func downloadClient() *http.Client {
+	timeout, _ := settingDuration(CurrentSettings.DownloadTimeout)
+	return &http.Client{Timeout: timeout}
+}
+
+// archiveSuffixes are the source archive formats decompressSource can extract
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar.xz", ".tar.bz2", ".zip"}
+
+// archiveSuffix returns the archive suffix of a source file name, "" if unsupported
+func archiveSuffix(name string) string {
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
 // This takes in a PackageInfo struct and a URL, checks if the source
 // is already extracted, if not, it extracts the source based on the
 // specified type (tar, zip, etc.) uses the previous funcs for
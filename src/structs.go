@@ -20,32 +20,48 @@
 
 package main
 
-// PackageInfo represents the JSON structure of a package recipe
+import "time"
+
+// PackageInfo represents the JSON structure of a package recipe, the current
+// schema version of it (see recipe.go for older versions and migrations)
 type PackageInfo struct {
-	Name        string   `json:"name"`        // Package name
-	Version     string   `json:"version"`     // Package version
-	Release     int      `json:"release"`     // Release number
-	Description string   `json:"description"` // Short description
-	Author      string   `json:"author"`      // Author of package
-	License     string   `json:"license"`     // License type (MIT, GPL, etc.)
-	Source      struct { // Source code info
-		URL    string `json:"url"`    // URL to download source code
-		Type   string `json:"type"`   // Archive type (zip, tar, etc.)
-		Sha256 string `json:"sha256"` // Checksum for verification
+	Repo          string            `json:"-"`              // Repository the recipe was fetched from, not part of the recipe
+	SchemaVersion int               `json:"schema_version"` // Recipe format version, RecipeSchemaVersion, recipes without one are version 1
+	Name          string            `json:"name"`           // Package name
+	Version       string            `json:"version"`        // Package version
+	Release       int               `json:"release"`        // Release number
+	Description   string            `json:"description"`    // Short description
+	Author        string            `json:"author"`         // Author of package
+	License       string            `json:"license"`        // License type (MIT, GPL, etc.)
+	Vars          map[string]string `json:"vars,omitempty"` // Recipe variables, ${name} in the source URL, build env and commands (see vars.go)
+	Source        struct {          // Source code info
+		URL     string `json:"url"`               // URL to download source code
+		Type    string `json:"type,omitempty"`    // Archive type (zip, tar, etc.)
+		Sha256  string `json:"sha256,omitempty"`  // Checksum for verification
+		Sha512  string `json:"sha512,omitempty"`  // Optional SHA-512 checksum
+		Blake2b string `json:"blake2b,omitempty"` // Optional BLAKE2b-512 checksum (b2sum)
 	} `json:"source"`
-	Dependencies map[string]string `json:"dependencies"` // Required dependencies
-	OptDeps      []struct {        // Optional dependencies groups
-		ID          int      `json:"id"`          // Group ID
-		Description string   `json:"description"` // Group description
-		Options     []string `json:"options"`     // List of options
-		Default     string   `json:"default"`     // Default option
-	} `json:"opt_dependencies"`
+	Dependencies      map[string]string `json:"dependencies,omitempty"`       // Required dependencies, at runtime
+	BuildDependencies map[string]string `json:"build_dependencies,omitempty"` // Only needed to build the package, installed before building it
+	CheckDependencies map[string]string `json:"check_dependencies,omitempty"` // Only needed to run its tests, like build dependencies
+	OptDeps           []struct {        // Optional dependencies groups
+		ID          int      `json:"id"`                // Group ID
+		Description string   `json:"description"`       // Group description
+		Options     []string `json:"options"`           // List of options
+		Default     string   `json:"default,omitempty"` // Default option
+	} `json:"opt_dependencies,omitempty"`
 	Build struct { // Build instructions
-		Kind      string            `json:"kind"`      // toCompile or preCompiled
-		Env       map[string]string `json:"env"`       // Environment variables for build
-		Prepare   []string          `json:"prepare"`   // Commands to prepare build
-		Install   []string          `json:"install"`   // Commands to install package
-		Uninstall []string          `json:"uninstall"` // Commands to uninstall package
+		Kind       string            `json:"kind"`                  // toCompile or preCompiled
+		Env        map[string]string `json:"env,omitempty"`         // Environment variables for build
+		System     string            `json:"system,omitempty"`      // Build system providing default commands (autotools, cmake, ...), see buildsystem.go
+		Args       []string          `json:"args,omitempty"`        // Extra arguments for the build system
+		Prepare    []string          `json:"prepare,omitempty"`     // Commands to prepare build
+		Build      []string          `json:"build,omitempty"`       // Commands to compile, run as build_user like prepare
+		Check      []string          `json:"check,omitempty"`       // Commands running the test suite, only with --check (see check.go)
+		Install    []string          `json:"install,omitempty"`     // Commands to install package
+		Uninstall  []string          `json:"uninstall,omitempty"`   // Commands to uninstall package
+		Script     string            `json:"script,omitempty"`      // Build script defining the phase functions, instead of the command lists (see script.go)
+		ScriptFile string            `json:"script_file,omitempty"` // Same, from a file relative to the recipes directory
 	} `json:"build"`
 }
 
@@ -57,16 +73,67 @@ type Manifest struct {
 
 // InstalledPkg represents a package entry in the manifest
 type InstalledPkg struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Release int64  `json:"release"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Release     int64     `json:"release"`
+	Repo        string    `json:"repo"`                                    // Repository it was installed from, updates stay on it
+	Reason      string    `json:"reason" toml:",omitempty"`                // ReasonExplicit, ReasonDependency or ReasonBuild, empty for packages installed before it was recorded
+	InstalledAt time.Time `json:"installed_at,omitzero" toml:",omitempty"` // When it was installed or last updated
+	Size        int64     `json:"size" toml:",omitzero"`                   // Bytes installed, 0 when unknown (from before sizes were recorded)
+	Depends     []string  `json:"depends,omitempty" toml:",omitempty"`     // Installed packages it needs, mandatory and chosen optional ones
+	Profile     string    `json:"profile,omitempty" toml:",omitempty"`     // Build profile it was built with, empty for none or precompiled packages
+}
+
+// install reasons recorded in the manifest
+const (
+	ReasonExplicit   = "explicit"   // asked for by the user
+	ReasonDependency = "dependency" // pulled in by another package
+	ReasonBuild      = "build"      // only needed to build another package
+)
+
+// Config is the whole config.toml, see config.go
+type Config struct {
+	Version  int                      `toml:"version"`            // Schema version, ConfigVersion for current configs
+	Settings Settings                 `toml:"settings"`           // Global options
+	Repos    map[string]RepoConfig    `toml:"repos"`              // Repositories by name
+	Profiles map[string]BuildProfile  `toml:"profiles,omitempty"` // Build profiles by name, see profile.go
+	Packages map[string]PackageConfig `toml:"packages,omitempty"` // Per-package options by package name
 }
 
-// RepoConfig holds repository information from the config file
+// Settings holds the global options from the [settings] table, empty values use the defaults
+type Settings struct {
+	CacheDir        string `toml:"cache_dir,omitempty"`         // Where sources are downloaded and built
+	Jobs            int    `toml:"jobs,omitzero"`               // Parallel build jobs, 0 means one per CPU
+	BuildUser       string `toml:"build_user,omitempty"`        // Unprivileged user running the build steps
+	CFlags          string `toml:"cflags,omitempty"`            // Exported as CFLAGS and CXXFLAGS for builds
+	LDFlags         string `toml:"ldflags,omitempty"`           // Exported as LDFLAGS for builds
+	Profile         string `toml:"profile,omitempty"`           // Build profile used unless a package or --profile picks another
+	Proxy           string `toml:"proxy,omitempty"`             // HTTP(S) proxy for downloads and git
+	DefaultRoot     string `toml:"default_root,omitempty"`      // Root used when --root isn't given
+	Parallelism     int    `toml:"parallelism,omitzero"`        // Repositories synced at the same time
+	SyncTimeout     string `toml:"sync_timeout,omitempty"`      // Time limit for syncing all repositories
+	DownloadTimeout string `toml:"download_timeout,omitempty"`  // Time limit for a single download
+	ChecksumPolicy  string `toml:"checksum_policy,omitempty"`   // strict or permissive, see checksum.go
+	RemoveBuildDeps bool   `toml:"remove_build_deps,omitempty"` // Uninstall build-only dependencies once a transaction is done
+	Check           bool   `toml:"check,omitempty"`             // Run the check phase of every build, like --check
+}
+
+// RepoConfig holds repository information from the [repos.<name>] tables of the config file
 type RepoConfig struct {
-	Name       string `toml:"-"`          // Optional, not in TOML
-	URL        string `toml:"git_url"`    // Maps git_url in TOML
-	Ref        string `toml:"branch"`     // Maps branch in TOML
-	Hash       string `toml:"hash"`       // Optional pinned commit
-	TrustedKey string `toml:"trustedKey"` // GPG key path as the root of the repository (eg. "/key.pub")
+	Name       string `toml:"-"`                     // Table name, not stored in the table itself
+	Type       string `toml:"type,omitempty"`        // Repository backend: git (default), local or http
+	URL        string `toml:"git_url,omitempty"`     // Maps git_url in TOML
+	Path       string `toml:"path,omitempty"`        // Directory of a local repository
+	BaseURL    string `toml:"url,omitempty"`         // Base URL of a static http repository
+	Ref        string `toml:"branch,omitempty"`      // Maps branch in TOML
+	Hash       string `toml:"hash,omitempty"`        // Optional pinned commit
+	Priority   int    `toml:"priority,omitzero"`     // Higher priority wins when several repos provide a package
+	Disabled   bool   `toml:"disabled,omitempty"`    // Kept in the config but not synced or searched
+	TrustedKey string `toml:"trusted_key,omitempty"` // DEPRECATED: key file inside the repository, replaced by TrustedKeys
+
+	TrustedKeys          []string `toml:"trusted_keys,omitempty"`           // Keyring fingerprints allowed to sign commits
+	AllowedSigners       string   `toml:"allowed_signers,omitempty"`        // ssh allowed_signers file for SSH signed commits
+	MaintainerKeys       []string `toml:"maintainer_keys,omitempty"`        // Keyring fingerprints allowed to sign recipes
+	RequireSignedRecipes bool     `toml:"require_signed_recipes,omitempty"` // Refuse recipes without a valid maintainer signature, implied by maintainer_keys
+	Expiry               string   `toml:"expiry,omitempty"`                 // Refuse the repo if HEAD is older than this (eg. "30d", "72h")
 }
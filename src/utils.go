@@ -22,13 +22,12 @@ package main
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/Aperture-OS/eyes"
 )
@@ -56,7 +55,17 @@ func checkDirAndCreate(path string) error {
 // without reusing the same code for 8 billion times
 
 func runCmd(name string, args ...string) error {
+	return runCmdAs(nil, nil, name, args...)
+}
+
+// runCmdAs is runCmd running the command as another user in env, a nil
+// credential keeps the current user and a nil env Blink's environment
+func runCmdAs(cred *syscall.Credential, env []string, name string, args ...string) error {
 	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	if cred != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	}
 
 	// Capture stderr for meaningful error messages
 	var stderr bytes.Buffer
@@ -69,23 +78,14 @@ func runCmd(name string, args ...string) error {
 	return nil
 }
 
-// compareSHA256 takes in a expectedHash (so a string which is a sha256), and
-// a file, it decodes the file's hash and checks if it matches the expectedHash,
-
-func compareSHA256(expectedHash, file string) (bool, error) { // takes a expectedHash and a file, it generates the file's sha256 and compares it with expectedHash
-	f, err := os.Open(file)
-	if err != nil {
-		return false, err
-	}
-	defer f.Close()
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return false, err
-	}
-
-	actual := hex.EncodeToString(h.Sum(nil))
-	return strings.EqualFold(actual, expectedHash), nil
+// chownTree hands a directory tree over to a user, used to let build_user write the build dir
+func chownTree(root string, uid, gid int) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(p, uid, gid)
+	})
 }
 
 // clean cleans the data folders like recipes and allat, yes thats it
@@ -106,7 +106,7 @@ func clean() error {
 
 		// Try to acquire the lock
 		if err := lock.Acquire(); err != nil {
-			eyes.Fatalf("Could not acquire lock: %v", err)
+			return fmt.Errorf("could not acquire lock: %v", err)
 		}
 
 		// Make sure to release the lock when done
@@ -126,7 +126,7 @@ func clean() error {
 		os.MkdirAll(BuildDirPath, 0755)
 
 	default:
-		eyes.Fatalf("\nUser declined, exiting...")
+		fatalf("User declined, exiting...")
 
 	}
 
@@ -153,8 +153,7 @@ func normalizeYesNo(s string) string {
 
 func requireRoot() {
 	if os.Geteuid() != 0 {
-		eyes.Fatalf(`This command must be run as Root or Super User (also known as Admin, Administrator, SU, etc.)
-		Please try again with 'sudo' infront of the command or as the root user ('su -').
-		`)
+		fatalf("This command must be run as Root or Super User (also known as Admin, Administrator, SU, etc.)\n" +
+			"Please try again with 'sudo' infront of the command or as the root user ('su -').")
 	}
 }
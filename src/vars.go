@@ -0,0 +1,241 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Recipe variables. ${name} in the source URL, build env values and command
+// lists is replaced before anything runs, so a version bump only touches the
+// version field. The built-in variables are below, a recipe adds its own in
+// vars, whose values may use other variables. $${ is a literal ${, for the
+// shell's own ${VAR:-default} and friends.
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// builtinRecipeVars are the variables every recipe has, with what they hold
+var builtinRecipeVars = map[string]string{
+	"name":    "package name",
+	"version": "package version",
+	"release": "package release",
+	"srcdir":  "unpacked source the build runs in, not known in source.url",
+	"pkgdir":  "directory the package is built in, the source is unpacked inside it",
+	"jobs":    "parallel build jobs, from the jobs setting",
+	"root":    "root directory the package is installed to",
+	"destdir": "staging directory the install step installs into, Blink copies it to root",
+	"arch":    "machine architecture, as uname -m prints it",
+}
+
+// unameArch maps GOARCH to what uname -m prints, upstream tarballs are named after it
+var unameArch = map[string]string{
+	"amd64":   "x86_64",
+	"386":     "i686",
+	"arm64":   "aarch64",
+	"arm":     "armv7l",
+	"ppc64le": "ppc64le",
+	"riscv64": "riscv64",
+	"s390x":   "s390x",
+}
+
+// RecipeVars expands the variables of one recipe
+type RecipeVars struct {
+	builtin map[string]string // built-in values known so far
+	user    map[string]string // the recipe's vars, expanded when used
+}
+
+// newRecipeVars returns the variables of pkg built in pkgdir. srcdir is only
+// known once the source is unpacked, see SetSrcDir
+func newRecipeVars(pkg PackageInfo, pkgdir string) (*RecipeVars, error) {
+	for name := range pkg.Vars {
+		if _, ok := builtinRecipeVars[name]; ok {
+			return nil, fmt.Errorf("vars.%s: %s is a built-in variable and can't be redefined", name, name)
+		}
+		if !validVarName(name) {
+			return nil, fmt.Errorf("vars.%s: invalid variable name, use letters, digits and _", name)
+		}
+	}
+
+	arch, ok := unameArch[runtime.GOARCH]
+	if !ok {
+		arch = runtime.GOARCH
+	}
+	jobs, err := buildJobs(pkg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecipeVars{
+		builtin: map[string]string{
+			"name":    pkg.Name,
+			"version": pkg.Version,
+			"release": strconv.Itoa(pkg.Release),
+			"pkgdir":  pkgdir,
+			"jobs":    strconv.Itoa(jobs),
+			"root":    RootDirPath,
+			"destdir": stagingDir(pkg.Name),
+			"arch":    arch,
+		},
+		user: pkg.Vars,
+	}, nil
+}
+
+// SetSrcDir makes ${srcdir} available, once the source is unpacked
+func (v *RecipeVars) SetSrcDir(dir string) {
+	v.builtin["srcdir"] = dir
+}
+
+// Expand replaces the variables in s
+func (v *RecipeVars) Expand(s string) (string, error) {
+	return v.expand(s, nil)
+}
+
+// expand replaces the variables in s, stack holds the user variables being
+// expanded to catch variables defined through themselves
+func (v *RecipeVars) expand(s string, stack []string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' { // $${ is a literal ${
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", s[i:])
+		}
+		name := s[i+2 : i+end]
+
+		value, err := v.lookup(name, stack)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[:i] + value)
+		s = s[i+end+1:]
+	}
+}
+
+// lookup returns the value of a variable
+func (v *RecipeVars) lookup(name string, stack []string) (string, error) {
+	if value, ok := v.builtin[name]; ok {
+		return value, nil
+	}
+	if _, ok := builtinRecipeVars[name]; ok {
+		return "", fmt.Errorf("${%s} is not known yet here", name)
+	}
+
+	raw, ok := v.user[name]
+	if !ok {
+		return "", fmt.Errorf("undefined variable ${%s} (known: %s, write $${%s} to leave it to the shell)",
+			name, strings.Join(v.names(), ", "), name)
+	}
+	for _, seen := range stack {
+		if seen == name {
+			return "", fmt.Errorf("variable ${%s} is defined through itself (%s -> %s)", name, strings.Join(stack, " -> "), name)
+		}
+	}
+	return v.expand(raw, append(stack, name))
+}
+
+// names lists every variable, built-in or not
+func (v *RecipeVars) names() []string {
+	names := make([]string, 0, len(builtinRecipeVars)+len(v.user))
+	for name := range builtinRecipeVars {
+		names = append(names, name)
+	}
+	for name := range v.user {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Environ returns every variable known so far as NAME=value, for build scripts
+func (v *RecipeVars) Environ() ([]string, error) {
+	var env []string
+	for _, name := range v.names() {
+		value, err := v.lookup(name, nil)
+		if err != nil {
+			if _, builtin := builtinRecipeVars[name]; builtin {
+				continue // srcdir before the source is unpacked
+			}
+			return nil, fmt.Errorf("vars.%s: %v", name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// ExpandSource expands the source URL, before the source is downloaded
+func (v *RecipeVars) ExpandSource(pkg *PackageInfo) error {
+	url, err := v.Expand(pkg.Source.URL)
+	if err != nil {
+		return fmt.Errorf("source.url: %v", err)
+	}
+	pkg.Source.URL = url
+	return nil
+}
+
+// ExpandBuild expands the build env values and command lists, once ${srcdir} is set
+func (v *RecipeVars) ExpandBuild(pkg *PackageInfo) error {
+	env := make(map[string]string, len(pkg.Build.Env))
+	for _, k := range sortedKeys(pkg.Build.Env) {
+		value, err := v.Expand(pkg.Build.Env[k])
+		if err != nil {
+			return fmt.Errorf("build.env.%s: %v", k, err)
+		}
+		env[k] = value
+	}
+	pkg.Build.Env = env
+
+	for _, list := range commandLists(pkg) {
+		expanded := make([]string, len(*list.Cmds))
+		for i, cmd := range *list.Cmds {
+			value, err := v.Expand(cmd)
+			if err != nil {
+				return fmt.Errorf("build.%s[%d]: %v", list.Name, i, err)
+			}
+			expanded[i] = value
+		}
+		*list.Cmds = expanded
+	}
+	return nil
+}
+
+// validVarName reports whether name can be a variable, letters, digits and _ not starting with a digit
+func validVarName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
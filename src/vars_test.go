@@ -0,0 +1,97 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecipeVarsExpand(t *testing.T) {
+	vars := &RecipeVars{
+		builtin: map[string]string{"name": "foo", "version": "1.0", "root": "/"},
+		user: map[string]string{
+			"tarball": "${name}-${version}.tar.gz",
+			"url":     "https://example.com/${tarball}",
+			"self":    "x${self}",
+			"a":       "${b}",
+			"b":       "${a}",
+		},
+	}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string // part of the error, "" when none is expected
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${name}-${version}", want: "foo-1.0"},
+		{in: "${url}", want: "https://example.com/foo-1.0.tar.gz"}, // variables using variables
+		{in: "$HOME", want: "$HOME"},                               // only ${...} is a variable
+		{in: "$${HOME}", want: "${HOME}"},                          // $${ is a literal ${
+		{in: "$${CC:-cc} ${name}", want: "${CC:-cc} foo"},
+		{in: "$${name}", want: "${name}"},
+		{in: "$$${name}", want: "$${name}"},
+		{in: "${nope}", wantErr: "undefined variable ${nope}"},
+		{in: "${srcdir}", wantErr: "not known yet"},
+		{in: "${self}", wantErr: "defined through itself (self -> self)"},
+		{in: "${a}", wantErr: "defined through itself (a -> b -> a)"},
+		{in: "${name", wantErr: "unterminated"},
+	}
+	for _, tt := range tests {
+		got, err := vars.Expand(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expand(%q) error = %v, want one containing %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expand(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRecipeVars(t *testing.T) {
+	tests := []struct {
+		vars    map[string]string
+		wantErr string
+	}{
+		{vars: map[string]string{"tarball": "${name}.tar.gz"}},
+		{vars: map[string]string{"version": "2.0"}, wantErr: "built-in variable"},
+		{vars: map[string]string{"1x": "y"}, wantErr: "invalid variable name"},
+		{vars: map[string]string{"a-b": "y"}, wantErr: "invalid variable name"},
+	}
+	for _, tt := range tests {
+		pkg := PackageInfo{Name: "foo", Version: "1.0", Release: 1, Vars: tt.vars}
+		_, err := newRecipeVars(pkg, "/tmp/foo")
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("newRecipeVars(%v) error = %v", tt.vars, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("newRecipeVars(%v) error = %v, want one containing %q", tt.vars, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+// Version comparison: versions are "[epoch:]upstream" plus the recipe's release
+// number. Upstream versions are compared segment by segment like rpm and pacman
+// do, so "1.10" is newer than "1.9", "1.0" is newer than "1.0rc1" and "1.0~beta"
+// sorts before "1.0". A higher epoch always wins, it's the escape hatch for
+// upstreams that changed their versioning scheme.
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Version is a parsed package version
+type Version struct {
+	Epoch    int    // optional "N:" prefix, 0 when missing
+	Upstream string // upstream version (eg. "1.2.3")
+	Release  int    // recipe release, 0 means unspecified (matches any release)
+}
+
+// String formats a version as [epoch:]upstream[-release]
+func (v Version) String() string {
+	s := v.Upstream
+	if v.Epoch > 0 {
+		s = fmt.Sprintf("%d:%s", v.Epoch, s)
+	}
+	if v.Release > 0 {
+		s = fmt.Sprintf("%s-%d", s, v.Release)
+	}
+	return s
+}
+
+// pkgVersion builds the version of a recipe or installed package
+func pkgVersion(version string, release int) Version {
+	epoch, upstream := splitEpoch(version)
+	return Version{Epoch: epoch, Upstream: upstream, Release: release}
+}
+
+// ParseVersion parses "[epoch:]upstream[-release]" as written by users and in
+// dependency constraints, a trailing "-N" is only a release when N is a number
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Version{}, fmt.Errorf("empty version")
+	}
+	if strings.ContainsFunc(s, unicode.IsSpace) {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	epoch, rest := splitEpoch(s)
+	v := Version{Epoch: epoch, Upstream: rest}
+
+	if i := strings.LastIndex(rest, "-"); i > 0 {
+		if release, err := strconv.Atoi(rest[i+1:]); err == nil && release >= 0 {
+			v.Upstream, v.Release = rest[:i], release
+		}
+	}
+
+	if v.Upstream == "" {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+// splitEpoch splits "N:version" into its epoch and version
+func splitEpoch(s string) (int, string) {
+	if before, after, ok := strings.Cut(s, ":"); ok {
+		if epoch, err := strconv.Atoi(before); err == nil && epoch >= 0 {
+			return epoch, after
+		}
+	}
+	return 0, s
+}
+
+// compareVersions returns -1, 0 or 1 when a is older than, equal to or newer than b
+func compareVersions(a, b Version) int {
+	if a.Epoch != b.Epoch {
+		return compareInts(a.Epoch, b.Epoch)
+	}
+	if c := compareUpstream(a.Upstream, b.Upstream); c != 0 {
+		return c
+	}
+	return compareInts(a.Release, b.Release)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareUpstream compares upstream versions (rpmvercmp): both are split into
+// runs of digits and runs of letters, anything else only separates segments.
+// Digits compare as numbers, letters as strings, and a numeric segment is newer
+// than an alphabetic one. Trailing letters and '~' mark pre-releases.
+func compareUpstream(a, b string) int {
+	for {
+		// skip separators, but stop at '~'
+		a = strings.TrimLeftFunc(a, isVersionSeparator)
+		b = strings.TrimLeftFunc(b, isVersionSeparator)
+
+		// tilde: pre-release, older than anything else
+		aTilde, bTilde := strings.HasPrefix(a, "~"), strings.HasPrefix(b, "~")
+		if aTilde || bTilde {
+			if aTilde && bTilde {
+				a, b = a[1:], b[1:]
+				continue
+			}
+			if aTilde {
+				return -1
+			}
+			return 1
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		// take one segment of the same kind from both
+		numeric := isDigit(a[0])
+		segA, restA := versionSegment(a, numeric)
+		segB, restB := versionSegment(b, numeric)
+
+		if segB == "" {
+			// different kinds: numeric is newer than alphabetic
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				return compareInts(len(segA), len(segB))
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+
+		a, b = restA, restB
+	}
+
+	// whoever has segments left is newer, unless they start with
+	// letters: "1.0rc1" and "1.0-beta" are pre-releases of "1.0"
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		if isDigit(b[0]) {
+			return -1
+		}
+		return 1
+	default:
+		if isDigit(a[0]) {
+			return 1
+		}
+		return -1
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isVersionSeparator(r rune) bool {
+	return r != '~' && !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// versionSegment splits the leading run of digits (or letters) off s
+func versionSegment(s string, numeric bool) (string, string) {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if numeric && !isDigit(c) || !numeric && !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			break
+		}
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+//===================================================================//
+//						  Dependency constraints
+//===================================================================//
+
+// Constraint is one version requirement of a dependency (eg. ">=1.0.0")
+type Constraint struct {
+	Op      string  // one of =, !=, <, <=, >, >=
+	Version Version // release 0 means any release of that version
+}
+
+// ParseConstraints parses a dependency's requirement, several constraints are
+// comma separated (">=1.0, <2.0"), an empty string or "*" allows any version
+func ParseConstraints(s string) ([]Constraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "*" {
+		return nil, nil
+	}
+
+	var constraints []Constraint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+
+		op := "="
+		for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op, part = candidate, strings.TrimSpace(part[len(candidate):])
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+
+		v, err := ParseVersion(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", s, err)
+		}
+		constraints = append(constraints, Constraint{Op: op, Version: v})
+	}
+
+	return constraints, nil
+}
+
+// Allows reports whether v satisfies the constraint
+func (c Constraint) Allows(v Version) bool {
+	if c.Version.Release == 0 {
+		v.Release = 0 // no release in the constraint, any release matches
+	}
+
+	cmp := compareVersions(v, c.Version)
+	switch c.Op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func (c Constraint) String() string {
+	return c.Op + c.Version.String()
+}
+
+// constraintsAllow reports whether v satisfies every constraint
+func constraintsAllow(constraints []Constraint, v Version) bool {
+	for _, c := range constraints {
+		if !c.Allows(v) {
+			return false
+		}
+	}
+	return true
+}
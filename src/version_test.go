@@ -0,0 +1,166 @@
+/*
+  Blink, a powerful source-based package manager. Core of ApertureOS.
+	Want to use it for your own project?
+	Blink is completely FOSS (Free and Open Source),
+	edit, publish, use, contribute to Blink however you prefer.
+  Copyright (C) 2025-2026 Aperture OS
+
+  This program is free software: you can redistribute it and/or modify
+  it under the terms of the Apache 2.0 License as published by
+  the Apache Software Foundation, either version 2.0 of the License, or
+  any later version.
+
+  This program is distributed in the hope that it will be useful,
+  but WITHOUT ANY WARRANTY; without even the implied warranty of
+  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+
+  You should have received a copy of the Apache 2.0 License
+  along with this program.  If not, see <https://www.apache.org/licenses/LICENSE-2.0>.
+*/
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareUpstream(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.99999", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0.0", "1.0", 1},
+		{"1.05", "1.5", 0}, // leading zeros don't count
+		{"1_0", "1.0", 0},  // any separator is a separator
+		{"1a", "1b", -1},
+		{"a", "1", -1}, // numeric is newer than alphabetic
+		{"1.0rc1", "1.0", -1},
+		{"1.0-beta", "1.0", -1},
+		{"1.0a", "1.0", -1},
+		{"1.0rc1", "1.0rc2", -1},
+		{"1.0~beta", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~rc1", "1.0rc1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareUpstream(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareUpstream(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareUpstream(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareUpstream(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b Version
+		want int
+	}{
+		{pkgVersion("1.0", 1), pkgVersion("1.0", 1), 0},
+		{pkgVersion("1.0", 2), pkgVersion("1.0", 1), 1},
+		{pkgVersion("1.1", 1), pkgVersion("1.0", 9), 1},
+		{pkgVersion("1:0.9", 1), pkgVersion("2.0", 1), 1}, // epoch wins
+		{pkgVersion("1:0.9", 1), pkgVersion("2:0.1", 1), -1},
+		{pkgVersion("0:1.0", 1), pkgVersion("1.0", 1), 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "1.2.3", want: Version{Upstream: "1.2.3"}},
+		{in: " 1.2.3 ", want: Version{Upstream: "1.2.3"}},
+		{in: "1.2.3-4", want: Version{Upstream: "1.2.3", Release: 4}},
+		{in: "2:1.2.3-4", want: Version{Epoch: 2, Upstream: "1.2.3", Release: 4}},
+		{in: "1.0-beta", want: Version{Upstream: "1.0-beta"}}, // not a release
+		{in: "x:1.0", want: Version{Upstream: "x:1.0"}},       // not an epoch
+		{in: "", wantErr: true},
+		{in: "1 0", wantErr: true},
+		{in: "1:", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string // each constraint as String() prints it
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "*", want: nil},
+		{in: "1.0", want: []string{"=1.0"}},
+		{in: "==1.0", want: []string{"=1.0"}},
+		{in: ">= 1.0", want: []string{">=1.0"}},
+		{in: ">=1.0, <2.0", want: []string{">=1.0", "<2.0"}},
+		{in: "!=1:1.0-2", want: []string{"!=1:1.0-2"}},
+		{in: ">=", wantErr: true},
+		{in: ">=1.0,", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseConstraints(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConstraints(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		var strs []string
+		for _, c := range got {
+			strs = append(strs, c.String())
+		}
+		if !slices.Equal(strs, tt.want) {
+			t.Errorf("ParseConstraints(%q) = %v, want %v", tt.in, strs, tt.want)
+		}
+	}
+}
+
+func TestConstraintsAllow(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    Version
+		want       bool
+	}{
+		{"", pkgVersion("0.1", 1), true},
+		{">=1.0", pkgVersion("1.0", 1), true},
+		{">=1.0", pkgVersion("1.0rc1", 1), false},
+		{"=1.0", pkgVersion("1.0", 5), true}, // no release in the constraint, any matches
+		{">=1.0.0-2", pkgVersion("1.0.0", 1), false},
+		{">=1.0.0-2", pkgVersion("1.0.0", 2), true},
+		{">=1.0, <2.0", pkgVersion("1.9.9", 1), true},
+		{">=1.0, <2.0", pkgVersion("2.0", 1), false},
+		{"<2.0", pkgVersion("1:0.1", 1), false},
+		{"!=1.5", pkgVersion("1.5", 3), false},
+	}
+	for _, tt := range tests {
+		constraints, err := ParseConstraints(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraints(%q): %v", tt.constraint, err)
+		}
+		if got := constraintsAllow(constraints, tt.version); got != tt.want {
+			t.Errorf("%q allows %s = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}